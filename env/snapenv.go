@@ -0,0 +1,151 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package env
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SnapEnv holds the values of the environment variables set by snapd.
+//
+// The variables which are always set for snap apps and hooks are required.
+// The rest are optional and may be empty, e.g. SNAP_INSTANCE_KEY for
+// snaps that are not installed in parallel.
+type SnapEnv struct {
+	// Required variables
+
+	// Snap is the value of SNAP
+	Snap string
+	// SnapCommon is the value of SNAP_COMMON
+	SnapCommon string
+	// SnapData is the value of SNAP_DATA
+	SnapData string
+	// SnapInstanceName is the value of SNAP_INSTANCE_NAME
+	SnapInstanceName string
+	// SnapName is the value of SNAP_NAME
+	SnapName string
+	// SnapRevision is the value of SNAP_REVISION
+	SnapRevision string
+
+	// Optional variables
+
+	// SnapUserData is the value of SNAP_USER_DATA
+	SnapUserData string
+	// SnapUserCommon is the value of SNAP_USER_COMMON
+	SnapUserCommon string
+	// SnapArch is the value of SNAP_ARCH
+	SnapArch string
+	// SnapLibraryPath is the value of SNAP_LIBRARY_PATH
+	SnapLibraryPath string
+	// SnapVersion is the value of SNAP_VERSION
+	SnapVersion string
+	// SnapInstanceKey is the value of SNAP_INSTANCE_KEY
+	SnapInstanceKey string
+	// SnapRealHome is the value of SNAP_REAL_HOME
+	SnapRealHome string
+	// SnapSaveData is the value of SNAP_SAVE_DATA
+	SnapSaveData string
+	// SnapUID is the value of SNAP_UID, or -1 if not set
+	SnapUID int
+	// SnapEUID is the value of SNAP_EUID, or -1 if not set
+	SnapEUID int
+}
+
+// IsParallelInstance returns true if the snap is installed with an instance key,
+// e.g. as my-snap_foo
+func (e SnapEnv) IsParallelInstance() bool {
+	return e.SnapInstanceKey != ""
+}
+
+// IsRoot returns true if the effective user of the process is root
+func (e SnapEnv) IsRoot() bool {
+	return e.SnapEUID == 0
+}
+
+// Load reads the snap environment variables from the process environment.
+// See LoadFrom.
+func Load() (SnapEnv, error) {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, found := strings.Cut(kv, "="); found {
+			vars[k] = v
+		}
+	}
+	return LoadFrom(vars)
+}
+
+// LoadFrom reads the snap environment variables from the given map.
+// It returns an error listing the required variables which are not set,
+// or if an optional variable has an invalid value.
+// The returned struct contains the values that are set, even on error.
+func LoadFrom(vars map[string]string) (SnapEnv, error) {
+	var missing []string
+	required := func(key string) string {
+		value := vars[key]
+		if value == "" {
+			missing = append(missing, key)
+		}
+		return value
+	}
+
+	e := SnapEnv{
+		Snap:             required(snapEnv),
+		SnapCommon:       required(snapCommonEnv),
+		SnapData:         required(snapDataEnv),
+		SnapInstanceName: required(snapInstNameEnv),
+		SnapName:         required(snapNameEnv),
+		SnapRevision:     required(snapRevEnv),
+
+		SnapUserData:    vars[snapUserDataEnv],
+		SnapUserCommon:  vars[snapUserCommonEnv],
+		SnapArch:        vars[snapArchEnv],
+		SnapLibraryPath: vars[snapLibraryPathEnv],
+		SnapVersion:     vars[snapVersionEnv],
+		SnapInstanceKey: vars[snapInstKeyEnv],
+		SnapRealHome:    vars[snapRealHomeEnv],
+		SnapSaveData:    vars[snapSaveDataEnv],
+	}
+
+	var err error
+	if e.SnapUID, err = parseID(vars, snapUIDEnv); err != nil {
+		return e, err
+	}
+	if e.SnapEUID, err = parseID(vars, snapEUIDEnv); err != nil {
+		return e, err
+	}
+
+	if len(missing) != 0 {
+		return e, fmt.Errorf("environment variables not set: %s", strings.Join(missing, ", "))
+	}
+	return e, nil
+}
+
+// parseID parses a user ID, returning -1 if not set
+func parseID(vars map[string]string, key string) (int, error) {
+	value := vars[key]
+	if value == "" {
+		return -1, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return -1, fmt.Errorf("invalid value for %s: '%s'", key, value)
+	}
+	return id, nil
+}
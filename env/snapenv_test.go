@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package env
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requiredVars() map[string]string {
+	return map[string]string{
+		snapEnv:         "/snap/testsnap/x1",
+		snapCommonEnv:   "/var/snap/testsnap/common",
+		snapDataEnv:     "/var/snap/testsnap/x1",
+		snapInstNameEnv: "testsnap",
+		snapNameEnv:     "testsnap",
+		snapRevEnv:      "x1",
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for k, v := range requiredVars() {
+		t.Setenv(k, v)
+	}
+	t.Setenv(snapArchEnv, "amd64")
+
+	e, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/snap/testsnap/x1", e.Snap)
+	assert.Equal(t, "amd64", e.SnapArch)
+}
+
+func TestLoadFrom(t *testing.T) {
+	t.Run("required", func(t *testing.T) {
+		e, err := LoadFrom(requiredVars())
+		require.NoError(t, err)
+		assert.Equal(t, SnapEnv{
+			Snap:             "/snap/testsnap/x1",
+			SnapCommon:       "/var/snap/testsnap/common",
+			SnapData:         "/var/snap/testsnap/x1",
+			SnapInstanceName: "testsnap",
+			SnapName:         "testsnap",
+			SnapRevision:     "x1",
+			SnapUID:          -1,
+			SnapEUID:         -1,
+		}, e)
+		assert.False(t, e.IsParallelInstance())
+		assert.False(t, e.IsRoot())
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		vars := requiredVars()
+		vars[snapInstNameEnv] = "testsnap_foo"
+		vars[snapInstKeyEnv] = "foo"
+		vars[snapUserDataEnv] = "/root/snap/testsnap/x1"
+		vars[snapUserCommonEnv] = "/root/snap/testsnap/common"
+		vars[snapArchEnv] = "arm64"
+		vars[snapLibraryPathEnv] = "/var/lib/snapd/lib/gl"
+		vars[snapVersionEnv] = "3.0.0"
+		vars[snapRealHomeEnv] = "/root"
+		vars[snapSaveDataEnv] = "/var/lib/snapd/save/snap/testsnap_foo"
+		vars[snapUIDEnv] = "0"
+		vars[snapEUIDEnv] = "0"
+
+		e, err := LoadFrom(vars)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", e.SnapInstanceKey)
+		assert.Equal(t, "/root/snap/testsnap/x1", e.SnapUserData)
+		assert.Equal(t, "/root/snap/testsnap/common", e.SnapUserCommon)
+		assert.Equal(t, "arm64", e.SnapArch)
+		assert.Equal(t, "/var/lib/snapd/lib/gl", e.SnapLibraryPath)
+		assert.Equal(t, "3.0.0", e.SnapVersion)
+		assert.Equal(t, "/root", e.SnapRealHome)
+		assert.Equal(t, "/var/lib/snapd/save/snap/testsnap_foo", e.SnapSaveData)
+		assert.Equal(t, 0, e.SnapUID)
+		assert.True(t, e.IsParallelInstance())
+		assert.True(t, e.IsRoot())
+	})
+
+	t.Run("missing required", func(t *testing.T) {
+		vars := requiredVars()
+		delete(vars, snapDataEnv)
+		vars[snapRevEnv] = ""
+
+		e, err := LoadFrom(vars)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SNAP_DATA, SNAP_REVISION")
+		assert.Equal(t, "/snap/testsnap/x1", e.Snap)
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		vars := requiredVars()
+		vars[snapEUIDEnv] = "root"
+
+		_, err := LoadFrom(vars)
+		require.Error(t, err)
+	})
+}
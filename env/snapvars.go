@@ -15,13 +15,6 @@
  */
 package env
 
-import (
-	"errors"
-	"os"
-
-	"github.com/canonical/edgex-snap-hooks/v3/log"
-)
-
 var (
 	// Snap contains the value of the SNAP environment variable.
 	Snap string
@@ -48,53 +41,41 @@ const (
 	// configuration profile
 	ProfileConfig = "profile"
 
-	snapEnv         = "SNAP"
-	snapCommonEnv   = "SNAP_COMMON"
-	snapDataEnv     = "SNAP_DATA"
-	snapInstNameEnv = "SNAP_INSTANCE_NAME"
-	snapNameEnv     = "SNAP_NAME"
-	snapRevEnv      = "SNAP_REVISION"
+	snapEnv            = "SNAP"
+	snapCommonEnv      = "SNAP_COMMON"
+	snapDataEnv        = "SNAP_DATA"
+	snapInstNameEnv    = "SNAP_INSTANCE_NAME"
+	snapNameEnv        = "SNAP_NAME"
+	snapRevEnv         = "SNAP_REVISION"
+	snapUserDataEnv    = "SNAP_USER_DATA"
+	snapUserCommonEnv  = "SNAP_USER_COMMON"
+	snapArchEnv        = "SNAP_ARCH"
+	snapLibraryPathEnv = "SNAP_LIBRARY_PATH"
+	snapVersionEnv     = "SNAP_VERSION"
+	snapInstKeyEnv     = "SNAP_INSTANCE_KEY"
+	snapRealHomeEnv    = "SNAP_REAL_HOME"
+	snapUIDEnv         = "SNAP_UID"
+	snapEUIDEnv        = "SNAP_EUID"
+	snapSaveDataEnv    = "SNAP_SAVE_DATA"
 )
 
 // getEnvVars populates global variables for each of the SNAP*
 // variables defined in the snap's environment
 func getEnvVars() error {
-	Snap = os.Getenv(snapEnv)
-	if Snap == "" {
-		return errors.New("SNAP is not set")
-	}
-
-	SnapCommon = os.Getenv(snapCommonEnv)
-	if SnapCommon == "" {
-		return errors.New("SNAP_COMMON is not set")
-	}
-
-	SnapData = os.Getenv(snapDataEnv)
-	if SnapData == "" {
-		return errors.New("SNAP_DATA is not set")
-	}
-
-	SnapInst = os.Getenv(snapInstNameEnv)
-	if SnapInst == "" {
-		return errors.New("SNAP_INSTANCE_NAME is not set")
-	}
-
-	SnapName = os.Getenv(snapNameEnv)
-	if SnapName == "" {
-		return errors.New("SNAP_NAME is not set")
-	}
+	e, err := Load()
 
-	SnapRev = os.Getenv(snapRevEnv)
-	if SnapRev == "" {
-		return errors.New("SNAP_REVISION_NAME is not set")
-	}
+	Snap = e.Snap
+	SnapCommon = e.SnapCommon
+	SnapData = e.SnapData
+	SnapInst = e.SnapInstanceName
+	SnapName = e.SnapName
+	SnapRev = e.SnapRevision
 
-	return nil
+	return err
 }
 
 func init() {
-	if err := getEnvVars(); err != nil {
-		log.Error(err)
-		os.Exit(1)
-	}
+	// Errors are ignored to avoid side effects when importing this package,
+	// e.g. outside of a snap environment. Use Load to handle them.
+	_ = getEnvVars()
 }
@@ -29,6 +29,7 @@ func TestEnvVars(t *testing.T) {
 	os.Setenv(snapCommonEnv, "/snap/testsnap/common")
 	os.Setenv(snapDataEnv, "/var/snap/testsnap/x1")
 	os.Setenv(snapInstNameEnv, "testsnap")
+	os.Setenv(snapNameEnv, "testsnap")
 	os.Setenv(snapRevEnv, "2112")
 
 	// Test
@@ -39,6 +40,7 @@ func TestEnvVars(t *testing.T) {
 	assert.Equal(t, Snap, "/snap/testsnap/x1")
 	assert.Equal(t, SnapCommon, "/snap/testsnap/common")
 	assert.Equal(t, snapNameEnv, "SNAP_NAME")
+	assert.Equal(t, SnapName, "testsnap")
 	assert.Equal(t, SnapData, "/var/snap/testsnap/x1")
 	assert.Equal(t, SnapInst, "testsnap")
 	assert.Equal(t, SnapRev, "2112")
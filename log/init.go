@@ -2,37 +2,94 @@ package log
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
 var (
 	debug           bool
-	snapInstanceKey string // used as default syslog tag and tag prefix
-	tag             string // syslog tag and stderr prefix
+	debugOnce       sync.Once // reads the debug option on first use
+	snapInstanceKey string    // used as default syslog tag and tag prefix
+	tag             string    // syslog tag and stderr prefix
 )
 
 func init() {
-	Init()
+	// Errors are ignored to avoid side effects when importing this package,
+	// e.g. outside of a snap environment. Call Init to handle them.
+	// The debug option is read on first use, not to run snapctl on import.
+	_ = initWriter()
 }
 
-func Init() {
-	value, err := exec.Command("snapctl", "get", "debug").CombinedOutput()
-	if err != nil {
-		stderr(err)
-		os.Exit(1)
+// Init initializes the logger from the snap environment.
+// It reads the snap's debug option and sets up the syslog writer.
+// Without Init, the syslog writer is set up when the package is loaded and
+// the debug option is read by the first Debug or Debugf call.
+// Init may be called again, for example to re-read the debug option.
+//
+// On error, the logger remains usable with fallback settings:
+// debug logging is disabled, the tag is the name of the executable, and
+// messages which cannot be written to syslog are written to standard error.
+func Init() error {
+	var errs []string
+
+	if err := initWriter(); err != nil {
+		errs = append(errs, err.Error())
 	}
-	debug = (string(bytes.TrimSpace(value)) == "true")
+
+	// skip the lazy reading of the debug option
+	debugOnce.Do(func() {})
+	if err := readDebug(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("error initializing logger: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// initWriter sets the tag from the snap environment and sets up the syslog writer
+func initWriter() error {
+	var errs []string
 
 	snapInstanceKey = os.Getenv("SNAP_INSTANCE_NAME")
 	if snapInstanceKey == "" {
-		stderr("SNAP_INSTANCE_NAME environment variable not set.")
-		os.Exit(1)
+		snapInstanceKey = filepath.Base(os.Args[0])
+		errs = append(errs, "SNAP_INSTANCE_NAME environment variable not set")
 	}
 	tag = snapInstanceKey
 
 	if err := setupSyslogWriter(tag); err != nil {
-		stderr(err)
-		os.Exit(1)
+		slog = nil
+		errs = append(errs, fmt.Sprintf("error setting up syslog writer: %s", err))
 	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// readDebug reads the snap's debug option
+func readDebug() error {
+	value, err := exec.Command("snapctl", "get", "debug").CombinedOutput()
+	if err != nil {
+		debug = false
+		return fmt.Errorf("error reading debug option: %s: %s", err, bytes.TrimSpace(value))
+	}
+	debug = (string(bytes.TrimSpace(value)) == "true")
+	return nil
+}
+
+// debugEnabled returns true if the snap's debug option is set to true,
+// reading it on first use
+func debugEnabled() bool {
+	debugOnce.Do(func() {
+		_ = readDebug()
+	})
+	return debug
 }
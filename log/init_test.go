@@ -17,7 +17,10 @@
 package log
 
 import (
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,3 +54,28 @@ func TestInitialize(t *testing.T) {
 		require.NotNil(t, slog)
 	})
 }
+
+func TestLazyDebug(t *testing.T) {
+	// a snapctl which records its calls
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\necho true\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "snapctl"), []byte(script), 0755))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	debugOnce = sync.Once{}
+	t.Cleanup(func() {
+		debug = false
+		debugOnce = sync.Once{}
+	})
+
+	Info("no debug option needed")
+	require.NoFileExists(t, calls)
+
+	Debug("reads the debug option")
+	Debugf("reads it %s", "once")
+	require.True(t, debug)
+	content, err := os.ReadFile(calls)
+	require.NoError(t, err)
+	require.Equal(t, "get debug\n", string(content))
+}
@@ -40,10 +40,15 @@ func SetComponentName(component string) {
 
 // Debug writes the given input to syslog (sev=LOG_DEBUG) if snap `debug`
 // configuration option is set to `true`.
+// It writes to standard error instead if syslog is not available.
 // It formats similar to fmt.Sprint
 func Debug(a ...interface{}) {
-	if debug {
-		slog.Debug(fmt.Sprint(a...))
+	if debugEnabled() {
+		if slog == nil {
+			stderr(a...)
+			return
+		}
+		slog.Debug(redact(fmt.Sprint(a...)))
 	}
 }
 
@@ -57,8 +62,9 @@ func Debugf(format string, a ...interface{}) {
 // Error writes the given input to syslog (sev=LOG_ERROR).
 // It formats similar to fmt.Sprint
 func Error(a ...interface{}) {
-	msg := fmt.Sprint(a...)
-	slog.Err(msg)
+	if slog != nil {
+		slog.Err(redact(fmt.Sprint(a...)))
+	}
 	// print to stderr as well so that snap command prints them on non-zero exit
 	stderr(a...)
 }
@@ -84,9 +90,14 @@ func Fatalf(format string, a ...interface{}) {
 }
 
 // Info writes the given input to syslog (sev=LOG_INFO).
+// It writes to standard error instead if syslog is not available.
 // It formats similar to fmt.Sprint
 func Info(a ...interface{}) {
-	slog.Info(fmt.Sprint(a...))
+	if slog == nil {
+		stderr(a...)
+		return
+	}
+	slog.Info(redact(fmt.Sprint(a...)))
 }
 
 // Infof writes the given input to syslog (sev=LOG_INFO).
@@ -96,9 +107,14 @@ func Infof(format string, a ...interface{}) {
 }
 
 // Warn writes the given input to syslog (sev=LOG_WARNING).
+// It writes to standard error instead if syslog is not available.
 // It formats similar to fmt.Sprint
 func Warn(a ...interface{}) {
-	slog.Warning(fmt.Sprint(a...))
+	if slog == nil {
+		stderr(a...)
+		return
+	}
+	slog.Warning(redact(fmt.Sprint(a...)))
 }
 
 // Warnf writes the given input to syslog (sev=LOG_WARNING).
@@ -112,7 +128,7 @@ func Warnf(format string, a ...interface{}) {
 func stderr(a ...interface{}) {
 	// Standard errors get collected with "snapd" as syslog app.
 	// We add the tag as prefix to distinguish these from other snapd logs.
-	fmt.Fprintf(os.Stderr, tag+": %s\n", a...)
+	fmt.Fprintf(os.Stderr, "%s: %s\n", tag, redact(fmt.Sprint(a...)))
 }
 
 func setupSyslogWriter(tag string) error {
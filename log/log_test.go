@@ -23,3 +23,40 @@ import (
 func TestSetComponentName(t *testing.T) {
 	SetComponentName("tester")
 }
+
+func TestFallbackToStderr(t *testing.T) {
+	writer := slog
+	t.Cleanup(func() { slog = writer })
+
+	// should not panic without a syslog writer
+	slog = nil
+	Debug("debug")
+	Info("info")
+	Warn("warn")
+	Error("error")
+}
+
+func TestRedact(t *testing.T) {
+	t.Cleanup(func() {
+		secretsMutex.Lock()
+		secrets, redactor = make(map[string]bool), nil
+		secretsMutex.Unlock()
+	})
+
+	if got := redact("password=secret"); got != "password=secret" {
+		t.Fatalf("unexpected redaction without secrets: %s", got)
+	}
+
+	AddSecret("secret", "", "secret-long")
+	for msg, expected := range map[string]string{
+		"password=secret":          "password=***",
+		"password=secret-long":     "password=***",
+		"secret and secret-long":   "*** and ***",
+		"no secret value in here?": "no *** value in here?",
+		"nothing to hide":          "nothing to hide",
+	} {
+		if got := redact(msg); got != expected {
+			t.Errorf("redact(%q) = %q, expected %q", msg, got, expected)
+		}
+	}
+}
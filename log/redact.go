@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package log
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redacted replaces the secret values in log output
+const redacted = "***"
+
+var (
+	secretsMutex sync.RWMutex
+	secrets      = make(map[string]bool)
+	redactor     *strings.Replacer
+)
+
+// AddSecret registers values, such as passwords, which are replaced
+// by *** in all log output of this package.
+// Empty values are ignored.
+// This function is thread-safe.
+func AddSecret(values ...string) {
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+
+	added := false
+	for _, v := range values {
+		if v != "" && !secrets[v] {
+			secrets[v] = true
+			added = true
+		}
+	}
+	if !added {
+		return
+	}
+
+	// replace the longest values first, in case a secret contains another
+	var list []string
+	for v := range secrets {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if len(list[i]) != len(list[j]) {
+			return len(list[i]) > len(list[j])
+		}
+		return list[i] < list[j]
+	})
+
+	var pairs []string
+	for _, v := range list {
+		pairs = append(pairs, v, redacted)
+	}
+	redactor = strings.NewReplacer(pairs...)
+}
+
+// redact replaces the registered secrets in the message
+func redact(msg string) string {
+	secretsMutex.RLock()
+	defer secretsMutex.RUnlock()
+
+	if redactor == nil {
+		return msg
+	}
+	return redactor.Replace(msg)
+}
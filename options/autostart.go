@@ -1,8 +1,8 @@
 package options
 
 import (
-	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/canonical/edgex-snap-hooks/v3/env"
@@ -12,14 +12,10 @@ import (
 
 func processAppAutostartOptions(apps []string) (map[string]*bool, error) {
 	// get the apps' json structure
-	jsonString, err := snapctl.Get("apps").Document().Run()
-	if err != nil {
-		return nil, fmt.Errorf("error reading 'apps' option: %s", err)
-	}
 	var options snapOptions
-	err = json.Unmarshal([]byte(jsonString), &options)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling 'apps' option: %s", err)
+	err := snapctl.GetInto(&options.Apps, "apps")
+	if err != nil && !snapctl.IsUnset(err) {
+		return nil, fmt.Errorf("error reading 'apps' option: %s", err)
 	}
 
 	appAutostart := make(map[string]*bool)
@@ -34,17 +30,22 @@ func processAppAutostartOptions(apps []string) (map[string]*bool, error) {
 }
 
 func processGlobalAutostartOptions(apps []string) (map[string]*bool, error) {
-	autostart, err := snapctl.Get("autostart").Run()
-	if err != nil {
+	var value interface{}
+	err := snapctl.GetInto(&value, "autostart")
+	if snapctl.IsUnset(err) {
+		return make(map[string]*bool), nil
+	} else if err != nil {
 		return nil, fmt.Errorf("error reading 'autostart' option: %s", err)
 	}
 
+	autostart, err := parseAutostart(value)
+	if err != nil {
+		return nil, err
+	}
+
 	appAutostart := make(map[string]*bool)
 	for _, app := range apps {
-		appAutostart[app], err = parseAutostart(autostart)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing autostart for %s: %v", app, err)
-		}
+		appAutostart[app] = autostart
 		if appAutostart[app] != nil {
 			log.Debugf("%s: autostart=%t (global setting)", app, *appAutostart[app])
 		}
@@ -53,43 +54,121 @@ func processGlobalAutostartOptions(apps []string) (map[string]*bool, error) {
 	return appAutostart, nil
 }
 
-func parseAutostart(value string) (*bool, error) {
-	value = strings.ToLower(value)
-	switch value {
-	case "":
-		return nil, nil
-	// need to accept yes/no for EdgeX 2 backward compatibility
-	case "true", "yes":
-		b := true
-		return &b, nil
-	case "false", "no":
-		b := false
-		return &b, nil
+func parseAutostart(value interface{}) (*bool, error) {
+	var b bool
+	switch v := value.(type) {
+	case bool:
+		b = v
+	case string:
+		switch strings.ToLower(v) {
+		case "":
+			return nil, nil
+		// need to accept yes/no for EdgeX 2 backward compatibility
+		case "true", "yes":
+			b = true
+		case "false", "no":
+			b = false
+		default:
+			return nil, fmt.Errorf("invalid value for 'autostart': '%s'", v)
+		}
 	default:
-		return nil, fmt.Errorf("invalid value for 'autostart': '%s'", value)
+		return nil, fmt.Errorf("invalid value for 'autostart': '%v'", v)
 	}
+	return &b, nil
 }
 
 // ProcessAutostart will start and enable the listed app(s)
-// based on the value of autostart snap option
+// based on the value of autostart snap option.
+//
+// ProcessAutostart uses the package-level settings, which have no dependencies
+// between apps. Use a Processor with WithAutostartDependency to declare them.
 func ProcessAutostart(apps ...string) error {
+	return globalProcessor().ProcessAutostart(apps...)
+}
+
+// ProcessAutostartChanges is similar to ProcessAutostart, but returns
+// the services which have been started or stopped, and those left unchanged.
+func ProcessAutostartChanges(apps ...string) (*AutostartPlan, error) {
+	return globalProcessor().ProcessAutostartChanges(apps...)
+}
+
+// PlanAutostart is a dry run of ProcessAutostart.
+// It returns the services that would be started or stopped, without doing so.
+func PlanAutostart(apps ...string) (*AutostartPlan, error) {
+	return globalProcessor().PlanAutostart(apps...)
+}
+
+// ProcessAutostart will start and enable the listed app(s)
+// based on the value of autostart snap option.
+//
+// Enabling an app also enables its prerequisites, declared via WithAutostartDependency.
+// Disabling a prerequisite logs a warning for the apps which depend on it,
+// or also disables them if WithAutostartCascade is set.
+// Services are started one at a time, after their prerequisites,
+// and stopped one at a time, before their prerequisites.
+// Services which are already in the desired state are left alone.
+// Starting apps which require plugs, declared via WithRequiredPlugs,
+// is deferred until the plugs are connected.
+func (p *Processor) ProcessAutostart(apps ...string) error {
+	_, err := p.ProcessAutostartChanges(apps...)
+	return err
+}
+
+// ProcessAutostartChanges is similar to ProcessAutostart, but returns
+// the services which have been started or stopped, and those left unchanged.
+func (p *Processor) ProcessAutostartChanges(apps ...string) (*AutostartPlan, error) {
+	plan, err := p.PlanAutostart(apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, runAutostart(plan)
+}
+
+// runAutostart starts and stops the services of the plan, in order
+func runAutostart(plan *AutostartPlan) error {
+	for _, service := range plan.Start {
+		if err := snapctl.Start(service).Enable().Run(); err != nil {
+			return fmt.Errorf("error starting services: %s", err)
+		}
+	}
+	for _, service := range plan.Stop {
+		if err := snapctl.Stop(service).Disable().Run(); err != nil {
+			return fmt.Errorf("error stopping service: %s", err)
+		}
+	}
+	return nil
+}
+
+// PlanAutostart is a dry run of ProcessAutostart.
+// It returns the services that would be started or stopped, in order, without doing so.
+// The desired state of the services is compared with their current state,
+// and services which are already enabled and active, or disabled and inactive,
+// are listed as unchanged.
+func (p *Processor) PlanAutostart(apps ...string) (*AutostartPlan, error) {
+	return p.planAutostart(apps, newPlugStates())
+}
+
+// planAutostart plans the autostart of the apps, with the given connection states of plugs
+func (p *Processor) planAutostart(apps []string, plugs *plugStates) (*AutostartPlan, error) {
 	if len(apps) == 0 {
-		return fmt.Errorf("empty apps list")
+		return nil, fmt.Errorf("empty apps list")
 	}
 
 	log.Infof("Processing autostart for: %v", apps)
 
 	globalAppAutostart, err := processGlobalAutostartOptions(apps)
 	if err != nil {
-		return fmt.Errorf("error processing global autostart option: %s", err)
+		return nil, fmt.Errorf("error processing global autostart option: %s", err)
 	}
 
 	appAutostart, err := processAppAutostartOptions(apps)
 	if err != nil {
-		return fmt.Errorf("error processing global autostart option: %s", err)
+		return nil, fmt.Errorf("error processing global autostart option: %s", err)
 	}
 
-	var startList, stopList []string
+	start := make(map[string]bool)
+	stop := make(map[string]bool)
 	for _, app := range apps {
 		autostart := globalAppAutostart[app]
 		// app setting takes precedence over global setting
@@ -100,24 +179,230 @@ func ProcessAutostart(apps ...string) error {
 		if autostart != nil {
 			if *autostart {
 				log.Infof("%s will start and enable.", app)
-				startList = append(startList, env.SnapName+"."+app)
+				start[app] = true
 			} else {
 				log.Infof("%s will stop and disable!", app)
-				stopList = append(stopList, env.SnapName+"."+app)
+				stop[app] = true
 			}
 		}
 	}
 
-	if len(startList) > 0 {
-		if err := snapctl.Start(startList...).Enable().Run(); err != nil {
-			return fmt.Errorf("error starting services: %s", err)
+	// disabled prerequisites disable the apps which depend on them
+	if p.autostartCascade {
+		for _, app := range p.autostartOrder(apps, stop) {
+			for _, dependent := range p.autostartDeps.dependents(app) {
+				if !stop[dependent] {
+					log.Warnf("%s will stop and disable, since it depends on %s!", dependent, app)
+					delete(start, dependent)
+					stop[dependent] = true
+				}
+			}
 		}
 	}
-	if len(stopList) > 0 {
-		if err := snapctl.Stop(stopList...).Disable().Run(); err != nil {
-			return fmt.Errorf("error stopping service: %s", err)
+
+	// enabled apps enable their prerequisites
+	for _, app := range p.autostartOrder(apps, start) {
+		for _, prerequisite := range p.autostartDeps.prerequisites(app) {
+			if stop[prerequisite] {
+				log.Warnf("%s depends on %s, which will stop and disable!", app, prerequisite)
+			} else if !start[prerequisite] {
+				log.Infof("%s will start and enable, since %s depends on it.", prerequisite, app)
+				start[prerequisite] = true
+			}
+		}
+	}
+
+	// without cascade, warn about the apps which depend on disabled ones
+	if !p.autostartCascade {
+		for _, app := range p.autostartOrder(apps, stop) {
+			for _, dependent := range p.autostartDeps.dependents(app) {
+				if !stop[dependent] && !start[dependent] {
+					log.Warnf("%s depends on %s, which will stop and disable!", dependent, app)
+				}
+			}
+		}
+	}
+
+	// defer apps with disconnected plugs, and those which depend on them
+	deferred := make(map[string]bool)
+	for _, app := range p.autostartOrder(apps, start) {
+		disconnected, err := plugs.disconnected(p.requiredPlugs[app])
+		if err != nil {
+			return nil, err
+		}
+		if len(disconnected) != 0 {
+			log.Infof("%s will start once %s connected.", app, describePlugs(disconnected))
+			deferred[app] = true
+			continue
+		}
+		for _, prerequisite := range p.autostartDeps[app] {
+			if deferred[prerequisite] {
+				log.Infof("%s will start once %s starts.", app, prerequisite)
+				deferred[app] = true
+				break
+			}
 		}
 	}
 
+	var desired AutostartPlan
+	for _, app := range p.autostartOrder(apps, start) {
+		if deferred[app] {
+			desired.Deferred = append(desired.Deferred, env.SnapName+"."+app)
+		} else {
+			desired.Start = append(desired.Start, env.SnapName+"."+app)
+		}
+	}
+	stopOrder := p.autostartOrder(apps, stop)
+	for i := len(stopOrder) - 1; i >= 0; i-- {
+		desired.Stop = append(desired.Stop, env.SnapName+"."+stopOrder[i])
+	}
+
+	return reconcileAutostart(desired)
+}
+
+// reconcileAutostart removes the services which are already
+// in the desired state from the plan
+func reconcileAutostart(desired AutostartPlan) (*AutostartPlan, error) {
+	services := append(append([]string{}, desired.Start...), desired.Stop...)
+	if len(services) == 0 {
+		return &desired, nil
+	}
+
+	status, err := snapctl.Services(services...).Run()
+	if err != nil {
+		return nil, fmt.Errorf("error getting status of services: %s", err)
+	}
+
+	plan := AutostartPlan{Deferred: desired.Deferred}
+	for _, service := range desired.Start {
+		if s := status[service]; s.Enabled && s.Active {
+			log.Debugf("%s is already enabled and active", service)
+			plan.Unchanged = append(plan.Unchanged, service)
+		} else {
+			plan.Start = append(plan.Start, service)
+		}
+	}
+	for _, service := range desired.Stop {
+		if s := status[service]; !s.Enabled && !s.Active {
+			log.Debugf("%s is already disabled and inactive", service)
+			plan.Unchanged = append(plan.Unchanged, service)
+		} else {
+			plan.Stop = append(plan.Stop, service)
+		}
+	}
+	sort.Strings(plan.Unchanged)
+	return &plan, nil
+}
+
+// autostartOrder returns the selected apps, each after its prerequisites.
+// Independent apps keep the order of the given apps, followed by other apps sorted by name.
+func (p *Processor) autostartOrder(apps []string, selected map[string]bool) []string {
+	var others []string
+	for app := range selected {
+		if !contains(apps, app) {
+			others = append(others, app)
+		}
+	}
+	sort.Strings(others)
+
+	var order []string
+	for _, app := range p.autostartDeps.order(append(append([]string{}, apps...), others...)) {
+		if selected[app] {
+			order = append(order, app)
+		}
+	}
+	return order
+}
+
+// autostartDependencies maps apps to their direct prerequisites
+type autostartDependencies map[string][]string
+
+// prerequisites returns the direct and indirect prerequisites of the app
+func (d autostartDependencies) prerequisites(app string) []string {
+	return d.order(d[app])
+}
+
+// dependents returns the apps which directly or indirectly depend on the app, sorted
+func (d autostartDependencies) dependents(app string) []string {
+	var dependents []string
+	for other := range d {
+		if other != app && contains(d.prerequisites(other), app) {
+			dependents = append(dependents, other)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// order returns the apps and their prerequisites, each after its prerequisites.
+// The dependencies must not have cycles.
+func (d autostartDependencies) order(apps []string) []string {
+	var order []string
+	visited := make(map[string]bool)
+
+	var visit func(app string)
+	visit = func(app string) {
+		if visited[app] {
+			return
+		}
+		visited[app] = true
+		for _, prerequisite := range d[app] {
+			visit(prerequisite)
+		}
+		order = append(order, app)
+	}
+	for _, app := range apps {
+		visit(app)
+	}
+	return order
+}
+
+// validate returns an error naming the apps of a dependency cycle, if any
+func (d autostartDependencies) validate() error {
+	var apps []string
+	for app := range d {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(app string) error
+	visit = func(app string) error {
+		switch state[app] {
+		case visiting:
+			start := 0
+			for path[start] != app {
+				start++
+			}
+			cycle := append(append([]string{}, path[start:]...), app)
+			return fmt.Errorf("autostart dependency cycle: %s", strings.Join(cycle, " -> "))
+		case done:
+			return nil
+		}
+
+		state[app] = visiting
+		path = append(path, app)
+		for _, prerequisite := range d[app] {
+			if err := visit(prerequisite); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[app] = done
+		return nil
+	}
+
+	for _, app := range apps {
+		if err := visit(app); err != nil {
+			return err
+		}
+	}
 	return nil
 }
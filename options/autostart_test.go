@@ -5,6 +5,7 @@ import (
 
 	"github.com/canonical/edgex-snap-hooks/v3/options"
 	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
 	"github.com/stretchr/testify/require"
 )
 
@@ -124,3 +125,171 @@ func TestProcessAutostartAppOverride(t *testing.T) {
 	require.False(t, services[mockService2].Active, mockApp2+" active")
 	require.False(t, services[mockService2].Enabled, mockApp2+" enabled")
 }
+
+func TestProcessAutostartLegacyValues(t *testing.T) {
+	fake := installFake(t)
+	fake.AddService(mockService, false, false)
+	fake.AddService(mockService2, false, false)
+
+	t.Run("yes", func(t *testing.T) {
+		fake.SetConfig("autostart", "yes")
+		require.NoError(t, options.ProcessAutostart(mockApp, mockApp2))
+
+		for _, service := range []string{mockService, mockService2} {
+			enabled, active, _ := fake.Service(service)
+			require.True(t, enabled, service+" enabled")
+			require.True(t, active, service+" active")
+		}
+	})
+
+	t.Run("no", func(t *testing.T) {
+		fake.SetConfig("autostart", "no")
+		require.NoError(t, options.ProcessAutostart(mockApp, mockApp2))
+
+		for _, service := range []string{mockService, mockService2} {
+			enabled, active, _ := fake.Service(service)
+			require.False(t, enabled, service+" enabled")
+			require.False(t, active, service+" active")
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) { // should have no effect
+		fake.SetConfig("autostart", "")
+		fake.ResetCalls()
+		require.NoError(t, options.ProcessAutostart(mockApp, mockApp2))
+		for _, call := range fake.Calls() {
+			require.NotContains(t, []string{"start", "stop"}, call.Subcommand)
+		}
+	})
+
+	t.Run("reject invalid", func(t *testing.T) {
+		fake.SetConfig("autostart", "maybe")
+		require.Error(t, options.ProcessAutostart(mockApp, mockApp2))
+
+		fake.SetConfig("autostart", 1)
+		require.Error(t, options.ProcessAutostart(mockApp, mockApp2))
+	})
+}
+
+func TestProcessAutostartDependencies(t *testing.T) {
+	const (
+		coreData    = "core-data"
+		messageBus  = "message-bus"
+		secretStore = "secret-store"
+		rulesEngine = "rules-engine"
+	)
+	service := func(app string) string {
+		return "edgex-snap-hooks." + app
+	}
+	install := func(t *testing.T, running bool) *snapctltest.Fake {
+		fake := installFake(t)
+		for _, app := range []string{coreData, messageBus, secretStore, rulesEngine} {
+			fake.AddService(service(app), running, running)
+		}
+		return fake
+	}
+	newProcessor := func(t *testing.T, opts ...options.ProcessorOption) *options.Processor {
+		opts = append([]options.ProcessorOption{
+			options.WithAutostartDependency(coreData, messageBus, secretStore),
+			options.WithAutostartDependency(rulesEngine, coreData),
+			options.WithAutostartDependency(messageBus, secretStore),
+		}, opts...)
+		p, err := options.NewProcessor(opts...)
+		require.NoError(t, err)
+		return p
+	}
+
+	t.Run("start prerequisites in order", func(t *testing.T) {
+		fake := install(t, false)
+		fake.SetConfig("apps."+rulesEngine+".autostart", true)
+
+		p := newProcessor(t)
+		require.NoError(t, p.ProcessAutostart(rulesEngine, coreData))
+
+		var started []string
+		for _, call := range fake.Calls() {
+			if call.Subcommand == "start" {
+				started = append(started, call.Args[len(call.Args)-1])
+			}
+		}
+		require.Equal(t, []string{
+			service(secretStore), service(messageBus), service(coreData), service(rulesEngine),
+		}, started)
+		for _, app := range []string{coreData, messageBus, secretStore, rulesEngine} {
+			enabled, active, _ := fake.Service(service(app))
+			require.True(t, enabled, app+" enabled")
+			require.True(t, active, app+" active")
+		}
+	})
+
+	t.Run("warn on disabled prerequisite", func(t *testing.T) {
+		fake := install(t, true)
+		fake.SetConfig("autostart", true)
+		fake.SetConfig("apps."+messageBus+".autostart", false)
+
+		plan, err := newProcessor(t).PlanAutostart(coreData, messageBus, rulesEngine)
+		require.NoError(t, err)
+		require.Empty(t, plan.Start)
+		require.Equal(t, []string{service(messageBus)}, plan.Stop)
+		require.Equal(t, []string{service(coreData), service(rulesEngine), service(secretStore)}, plan.Unchanged)
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		fake := install(t, true)
+		fake.SetConfig("autostart", true)
+		fake.SetConfig("apps."+messageBus+".autostart", false)
+
+		plan, err := newProcessor(t, options.WithAutostartCascade()).PlanAutostart(coreData, messageBus, rulesEngine)
+		require.NoError(t, err)
+		// the prerequisites of stopped apps are not started
+		require.Empty(t, plan.Start)
+		// dependents stop first
+		require.Equal(t, []string{service(rulesEngine), service(coreData), service(messageBus)}, plan.Stop)
+	})
+
+	t.Run("reject cycles", func(t *testing.T) {
+		_, err := options.NewProcessor(
+			options.WithAutostartDependency(coreData, messageBus),
+			options.WithAutostartDependency(messageBus, secretStore),
+			options.WithAutostartDependency(secretStore, coreData),
+		)
+		require.EqualError(t, err, "autostart dependency cycle: core-data -> message-bus -> secret-store -> core-data")
+
+		_, err = options.NewProcessor(options.WithAutostartDependency(coreData, coreData))
+		require.EqualError(t, err, "autostart dependency cycle: core-data -> core-data")
+	})
+}
+
+func TestProcessAutostartReconcile(t *testing.T) {
+	fake := installFake(t)
+	fake.AddService(mockService, true, true)
+	fake.AddService(mockService2, true, false)
+	fake.SetConfig("autostart", true)
+
+	report, err := options.ProcessAutostartChanges(mockApp, mockApp2)
+	require.NoError(t, err)
+	require.Equal(t, &options.AutostartPlan{
+		Start:     []string{mockService2},
+		Unchanged: []string{mockService},
+	}, report)
+	enabled, active, _ := fake.Service(mockService2)
+	require.True(t, enabled, mockService2+" enabled")
+	require.True(t, active, mockService2+" active")
+
+	// nothing left to do
+	fake.ResetCalls()
+	report, err = options.ProcessAutostartChanges(mockApp, mockApp2)
+	require.NoError(t, err)
+	require.Empty(t, report.Start)
+	require.Empty(t, report.Stop)
+	require.Equal(t, []string{mockService, mockService2}, report.Unchanged)
+	for _, call := range fake.Calls() {
+		require.NotContains(t, []string{"start", "stop"}, call.Subcommand)
+	}
+
+	fake.SetConfig("apps."+mockApp+".autostart", false)
+	report, err = options.ProcessAutostartChanges(mockApp, mockApp2)
+	require.NoError(t, err)
+	require.Equal(t, []string{mockService}, report.Stop)
+	require.Equal(t, []string{mockService2}, report.Unchanged)
+}
@@ -17,119 +17,430 @@
 package options
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/canonical/edgex-snap-hooks/v3/env"
 	"github.com/canonical/edgex-snap-hooks/v3/log"
 )
 
 type configProcessor struct {
-	appEnvVars            map[string]map[string]string
-	envSegmentSeparator   string
-	envHierarchySeparator string
-	configHierarchy       bool
+	*Processor
+	appEnvVars map[string]map[string]string
+	// appEnvSources maps the env vars of each app to the snap options which set them
+	appEnvSources map[string]map[string]string
+	// appProfiles holds the selected profile of each app with profiles
+	appProfiles map[string]string
+	// appSecrets holds the env vars of each app which are set by secret options
+	appSecrets map[string]map[string]bool
 }
 
-func newConfigProcessor(apps []string, hierarchy bool, hSep, sSep string) *configProcessor {
+func newConfigProcessor(p *Processor, apps []string) *configProcessor {
 	cp := configProcessor{
-		appEnvVars:            make(map[string]map[string]string),
-		configHierarchy:       hierarchy,
-		envHierarchySeparator: hSep,
-		envSegmentSeparator:   sSep,
+		Processor:     p,
+		appEnvVars:    make(map[string]map[string]string),
+		appEnvSources: make(map[string]map[string]string),
+		appProfiles:   make(map[string]string),
+		appSecrets:    make(map[string]map[string]bool),
 	}
 	for _, app := range apps {
 		cp.appEnvVars[app] = make(map[string]string)
+		cp.appEnvSources[app] = make(map[string]string)
+		cp.appSecrets[app] = make(map[string]bool)
 	}
 	return &cp
 }
 
-// add app's env var to memory
-func (cp *configProcessor) addEnvVar(app, key, value string) error {
+// add app's env var to memory, along with the snap option which set it
+// and whether that option is secret
+func (cp *configProcessor) addEnvVar(app, source, key, value string, secret bool) error {
 	envKey, err := cp.configKeyToEnvVar(key)
 	if err != nil {
 		return fmt.Errorf("error converting config key to environment variable key: %s", err)
 	}
 	log.Infof("Mapping %s to %s", key, envKey)
+	if previous, found := cp.appEnvSources[app][envKey]; found && previous != source {
+		log.Debugf("%s: %s overrides %s for %s", envKey, source, previous, app)
+	}
 	cp.appEnvVars[app][envKey] = value
+	cp.appEnvSources[app][envKey] = source
+	if secret {
+		log.AddSecret(value)
+		cp.appSecrets[app][envKey] = true
+	} else {
+		delete(cp.appSecrets[app], envKey)
+	}
 	return err
 }
 
+// checkCollisions returns an error if multiple config options of one scope,
+// e.g. config.a-b and config.a.b, map to the same environment variable
+func (cp *configProcessor) checkCollisions(scope string, config *flatConfig) error {
+	// options setting the same flattened key
+	if len(config.collisions) != 0 {
+		c := config.collisions[0]
+		envKey, err := cp.configKeyToEnvVar(c.key)
+		if err != nil {
+			return fmt.Errorf("error converting config key to environment variable key: %s", err)
+		}
+		options := []string{scope + "." + c.previous, scope + "." + c.source}
+		sort.Strings(options)
+		return fmt.Errorf("config options %s and %s both map to environment variable %s",
+			options[0], options[1], envKey)
+	}
+
+	// options setting different flattened keys
+	var keys []string
+	for key := range config.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mapped := make(map[string]string)
+	for _, key := range keys {
+		envKey, err := cp.configKeyToEnvVar(key)
+		if err != nil {
+			return fmt.Errorf("error converting config key to environment variable key: %s", err)
+		}
+		option := scope + "." + config.source(key)
+		if previous, found := mapped[envKey]; found && previous != option {
+			return fmt.Errorf("config options %s and %s both map to environment variable %s",
+				previous, option, envKey)
+		}
+		mapped[envKey] = option
+	}
+	return nil
+}
+
 // convert snap option key to environment variable name
 func (cp *configProcessor) configKeyToEnvVar(configKey string) (string, error) {
-	if cp.configHierarchy {
-		configKey = strings.ReplaceAll(configKey, ".", cp.envHierarchySeparator)
+	if cp.hierarchy {
+		configKey = strings.ReplaceAll(configKey, ".", cp.hierarchySeparator)
 	} else if strings.Contains(configKey, ".") {
 		return "", fmt.Errorf("config key must not contain dots: %s", configKey)
 	}
 
-	return strings.ToUpper(
-		// replace the segment separator
-		strings.ReplaceAll(configKey, "-", cp.envSegmentSeparator),
-	), nil
+	// replace the segment separator
+	envKey := strings.ReplaceAll(configKey, "-", cp.segmentSeparator)
+
+	switch cp.casing {
+	case LowerCase:
+		return strings.ToLower(envKey), nil
+	case PreserveCase:
+		return envKey, nil
+	default:
+		return strings.ToUpper(envKey), nil
+	}
 }
 
 // returns the suitable env file name for the service
-func (cp *configProcessor) filename(service string) string {
-	// The app-service-configurable snap is the one outlier snap that doesn't
-	// include the service name in it's configuration path.
-	var path string
-	if env.SnapName == "edgex-app-service-configurable" {
-		path = fmt.Sprintf("%s/config/overrides.env", env.SnapData)
-	} else {
-		path = fmt.Sprintf("%s/config/%s/overrides.env", env.SnapData, service)
+func (cp *configProcessor) filename(service string) (string, error) {
+	t := cp.envFileTemplate
+	if t == nil && env.SnapName == "edgex-app-service-configurable" {
+		t = appServiceConfigurableEnvFile
+	} else if t == nil {
+		t = defaultEnvFile
+	}
+
+	path, err := executeEnvFileTemplate(t, newEnvFileData(service))
+	if err != nil {
+		return "", fmt.Errorf("error getting env file of %s: %s", service, err)
 	}
-	return path
+	return path, nil
 }
 
-func (cp *configProcessor) writeEnvFiles() error {
-	for app, envVars := range cp.appEnvVars {
-		var buffer bytes.Buffer
-		filename := cp.filename(app)
-
-		// do not create a .env file if there are no snap options set for the app
-		// remove .env file if exists
-		if len(envVars) == 0 {
-			if err := os.RemoveAll(filename); err != nil {
-				return fmt.Errorf("failed to remove env file: %s", err)
+// the parsed templates of the default env file paths
+var (
+	defaultEnvFile                = template.Must(parseEnvFileTemplate(DefaultEnvFileTemplate))
+	appServiceConfigurableEnvFile = template.Must(parseEnvFileTemplate(appServiceConfigurableEnvFileTemplate))
+)
+
+// EnvFileData is the data available to env file path templates
+type EnvFileData struct {
+	// App is the name of the app, e.g. core-data
+	App string
+	// Snap is the value of $SNAP
+	Snap string
+	// SnapData is the value of $SNAP_DATA
+	SnapData string
+	// SnapCommon is the value of $SNAP_COMMON
+	SnapCommon string
+	// SnapName is the value of $SNAP_NAME
+	SnapName string
+	// SnapInstanceName is the value of $SNAP_INSTANCE_NAME
+	SnapInstanceName string
+}
+
+func newEnvFileData(app string) EnvFileData {
+	return EnvFileData{
+		App:              app,
+		Snap:             env.Snap,
+		SnapData:         env.SnapData,
+		SnapCommon:       env.SnapCommon,
+		SnapName:         env.SnapName,
+		SnapInstanceName: env.SnapInst,
+	}
+}
+
+// parseEnvFileTemplate parses the env file path template
+// and checks its fields with sample data
+func parseEnvFileTemplate(text string) (*template.Template, error) {
+	t, err := template.New("env file").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	sample := EnvFileData{
+		App:              "app",
+		Snap:             "/snap/app/x1",
+		SnapData:         "/var/snap/app/x1",
+		SnapCommon:       "/var/snap/app/common",
+		SnapName:         "app",
+		SnapInstanceName: "app",
+	}
+	if _, err := executeEnvFileTemplate(t, sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// executeEnvFileTemplate returns the env file path.
+// It returns an error if the path depends on an unset snap environment variable,
+// e.g. for {{.SnapData}}/config/{{.App}}/overrides.env without $SNAP_DATA,
+// instead of a path under the root directory.
+func executeEnvFileTemplate(t *template.Template, data EnvFileData) (string, error) {
+	// render the unset variables as markers to find those the path depends on
+	marked := data
+	markers := make(map[string]string)
+	for name, value := range map[string]*string{
+		"SNAP":               &marked.Snap,
+		"SNAP_DATA":          &marked.SnapData,
+		"SNAP_COMMON":        &marked.SnapCommon,
+		"SNAP_NAME":          &marked.SnapName,
+		"SNAP_INSTANCE_NAME": &marked.SnapInstanceName,
+	} {
+		if *value == "" {
+			*value = "${" + name + "}"
+			markers[name] = *value
+		}
+	}
+	if len(markers) != 0 {
+		var b strings.Builder
+		if err := t.Execute(&b, marked); err != nil {
+			return "", fmt.Errorf("error executing env file template: %s", err)
+		}
+		var unset []string
+		for name, marker := range markers {
+			if strings.Contains(b.String(), marker) {
+				unset = append(unset, name)
 			}
-			continue
 		}
+		if len(unset) != 0 {
+			sort.Strings(unset)
+			return "", fmt.Errorf("%s is not set", strings.Join(unset, ", "))
+		}
+	}
 
-		// Add comment on top of the file
-		if _, err := fmt.Fprintln(&buffer, "# Sys-gen env vars from snap options:"); err != nil {
-			return err
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error executing env file template: %s", err)
+	}
+	path := filepath.Clean(b.String())
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("env file path is not absolute: %s", path)
+	}
+	return path, nil
+}
+
+// plan compares the environment variables of all apps with those in the existing env files.
+// It returns an error if multiple apps have the same env file.
+func (cp *configProcessor) plan() (*ConfigPlan, error) {
+	var plan ConfigPlan
+	files := make(map[string]string)
+	for app, envVars := range cp.appEnvVars {
+		filename, err := cp.filename(app)
+		if err != nil {
+			return nil, err
 		}
+		if other, found := files[filename]; found {
+			apps := []string{app, other}
+			sort.Strings(apps)
+			return nil, fmt.Errorf("apps %s and %s have the same env file: %s", apps[0], apps[1], filename)
+		}
+		files[filename] = app
 
-		// add env vars to buffer
+		appPlan := AppEnvPlan{
+			App:            app,
+			File:           filename,
+			Planned:        make(map[string]string),
+			Sources:        cp.appEnvSources[app],
+			Profile:        cp.appProfiles[app],
+			SecretFile:     secretsFilename(filename),
+			PlannedSecrets: make(map[string]string),
+		}
 		for k, v := range envVars {
-			if _, err := fmt.Fprintf(&buffer, "%s=\"%s\"\n", k, v); err != nil {
-				return err
+			if cp.appSecrets[app][k] && cp.unsetSecrets && v == "" {
+				// an empty secret revokes the consumed one
+				log.Debugf("Revoking secret %s of %s", k, app)
+			} else if cp.appSecrets[app][k] {
+				appPlan.PlannedSecrets[k] = v
+			} else {
+				appPlan.Planned[k] = v
 			}
 		}
 
-		log.Infof("Writing to env file %s: %s", filename, strings.ReplaceAll(buffer.String(), "\n", " "))
-
-		dir := filepath.Dir(filename)
-		err := os.MkdirAll(dir, 0755)
+		content, current, err := readEnvFile(appPlan.File)
 		if err != nil {
-			return err
+			// the file gets overwritten
+			log.Warnf("Error reading env file %s: %s", appPlan.File, err)
+			appPlan.invalid = true
 		}
+		appPlan.Current = current
+		appPlan.content = content
 
-		tmp := filename + ".tmp"
-		err = os.WriteFile(tmp, buffer.Bytes(), 0644)
+		content, current, err = readEnvFile(appPlan.SecretFile)
 		if err != nil {
-			return fmt.Errorf("failed to write %s: %s", tmp, err)
+			// the file gets overwritten
+			log.Warnf("Error reading secrets file %s: %s", appPlan.SecretFile, err)
+			appPlan.secretInvalid = true
 		}
+		for _, v := range current {
+			log.AddSecret(v)
+		}
+		appPlan.CurrentSecrets = current
+		appPlan.secretContent = content
 
-		err = os.Rename(tmp, filename)
-		if err != nil {
-			return fmt.Errorf("failed to rename %s to %s: %s", tmp, filename, err)
+		if cp.unsetSecrets {
+			// keep the consumed secrets, unless set again or revoked
+			for k, v := range current {
+				if _, found := envVars[k]; !found {
+					appPlan.PlannedSecrets[k] = v
+				}
+			}
+		}
+
+		plan.Apps = append(plan.Apps, appPlan)
+	}
+
+	sort.Slice(plan.Apps, func(i, j int) bool {
+		return plan.Apps[i].App < plan.Apps[j].App
+	})
+	return &plan, nil
+}
+
+// writeEnvFiles writes the env and secrets files whose content differs from the existing files.
+// It returns the sorted names of the apps whose environment variables changed.
+// Files where only the comments differ, e.g. the source of a variable,
+// are rewritten without being reported as changed.
+func writeEnvFiles(plan *ConfigPlan) (changed []string, err error) {
+	for _, appPlan := range plan.Apps {
+		if appPlan.Changed() {
+			changed = append(changed, appPlan.App)
+		}
+
+		// the secrets are written first, for the env file not to get ahead of them
+		if !appPlan.needsSecretWrite() {
+			log.Debugf("Secrets file %s is unchanged", appPlan.SecretFile)
+		} else if len(appPlan.PlannedSecrets) == 0 {
+			if err := removeFile(appPlan.SecretFile); err != nil {
+				return nil, err
+			}
+		} else {
+			content := formatEnvFile(appPlan.PlannedSecrets, appPlan.Sources)
+			log.Infof("Writing secrets to %s: %s", appPlan.SecretFile, strings.Join(sortedKeys(appPlan.PlannedSecrets), " "))
+			if err := writeFile(appPlan.SecretFile, content, 0600); err != nil {
+				return nil, err
+			}
+		}
+
+		if !appPlan.needsWrite() {
+			log.Debugf("Env file %s is unchanged", appPlan.File)
+		} else if len(appPlan.Planned) == 0 {
+			// do not create a .env file if there are no snap options set for the app
+			// remove .env file if exists
+			if err := removeFile(appPlan.File); err != nil {
+				return nil, err
+			}
+		} else {
+			content := formatEnvFile(appPlan.Planned, appPlan.Sources)
+			log.Infof("Writing to env file %s: %s", appPlan.File, strings.ReplaceAll(string(content), "\n", " "))
+			if err := writeFile(appPlan.File, content, 0644); err != nil {
+				return nil, err
+			}
 		}
 	}
 
+	return changed, nil
+}
+
+// writeFile replaces the file atomically with the content and permissions
+func writeFile(filename string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	// remove leftovers, which would keep their permissions
+	tmp := filename + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return fmt.Errorf("failed to remove %s: %s", tmp, err)
+	}
+
+	err = os.WriteFile(tmp, content, perm)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %s", tmp, err)
+	}
+
+	err = os.Rename(tmp, filename)
+	if err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %s", tmp, filename, err)
+	}
+	return nil
+}
+
+func removeFile(filename string) error {
+	if err := os.RemoveAll(filename); err != nil {
+		return fmt.Errorf("failed to remove env file: %s", err)
+	}
+	log.Infof("Removed env file %s", filename)
 	return nil
 }
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readEnvFile returns the content and environment variables of an env file.
+// A missing file has no content and no environment variables.
+func readEnvFile(filename string) (content []byte, envVars map[string]string, err error) {
+	content, err = os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	envVars, err = parseEnvFile(content)
+	return content, envVars, err
+}
+
+func equalEnvVars(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if value, found := b[k]; !found || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const envFileHeader = "# Sys-gen env vars from snap options:"
+
+// The env files are sourced by the service wrapper scripts using bash.
+// The values are therefore written in double quotes, with the characters
+// that remain special inside double quotes escaped by a backslash.
+// Newlines are kept literally, which bash preserves inside double quotes.
+var envValueEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"$", `\$`,
+	"`", "\\`",
+)
+
+// formatEnvFile returns the content of an env file with the variables
+// sorted by name, so that the same variables always produce the same file.
+// Each variable is preceded by a comment naming its source, if known.
+func formatEnvFile(envVars, sources map[string]string) []byte {
+	var names []string
+	for k := range envVars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var buffer bytes.Buffer
+	buffer.WriteString(envFileHeader + "\n")
+	for _, k := range names {
+		if source, found := sources[k]; found {
+			buffer.WriteString("# source: " + source + "\n")
+		}
+		buffer.WriteString(formatEnvVar(k, envVars[k]) + "\n")
+	}
+	return buffer.Bytes()
+}
+
+// formatEnvVar returns the assignment of a double-quoted and escaped value
+func formatEnvVar(name, value string) string {
+	return fmt.Sprintf("%s=\"%s\"", name, envValueEscaper.Replace(value))
+}
+
+// parseEnvFile parses the content of an env file, as interpreted by bash.
+// It supports blank lines, comments, optional export prefixes and values
+// that are double-quoted, single-quoted or unquoted.
+func parseEnvFile(data []byte) (map[string]string, error) {
+	envVars := make(map[string]string)
+
+	reader := bufio.NewReader(bytes.NewReader(data))
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := readLine(reader)
+		if line == "" && err != nil {
+			break
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		trimmed = strings.TrimPrefix(trimmed, "export ")
+
+		name, rest, found := strings.Cut(trimmed, "=")
+		if !found || !validEnvName(name) {
+			return nil, fmt.Errorf("line %d: invalid variable assignment: %s", lineNumber, line)
+		}
+
+		var value string
+		switch {
+		case strings.HasPrefix(rest, `"`):
+			value, rest, err = parseDoubleQuoted(rest[1:], reader, &lineNumber)
+		case strings.HasPrefix(rest, "'"):
+			value, rest, err = parseSingleQuoted(rest[1:], reader, &lineNumber)
+		default:
+			value, rest, _ = strings.Cut(rest, " ")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("line %d: unexpected characters after value: %s", lineNumber, rest)
+		}
+
+		envVars[name] = value
+	}
+
+	return envVars, nil
+}
+
+// readLine returns the next line without the line break
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	return strings.TrimSuffix(line, "\n"), err
+}
+
+// parseDoubleQuoted returns the value up to the closing double quote and the rest of the line.
+// Inside double quotes, a backslash escapes only \ " $ ` and newlines.
+func parseDoubleQuoted(s string, reader *bufio.Reader, lineNumber *int) (value, rest string, err error) {
+	var b strings.Builder
+	for {
+		continued := false
+		for i := 0; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '"':
+				return b.String(), s[i+1:], nil
+			case '\\':
+				if i+1 == len(s) {
+					// line continuation
+					continued = true
+				} else if next := s[i+1]; strings.IndexByte("\\\"$`", next) != -1 {
+					b.WriteByte(next)
+					i++
+				} else {
+					b.WriteByte(c)
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+
+		if !continued {
+			b.WriteByte('\n')
+		}
+		if s, err = nextLine(reader, lineNumber); err != nil {
+			return "", "", fmt.Errorf("unterminated double-quoted value")
+		}
+	}
+}
+
+// parseSingleQuoted returns the value up to the closing single quote and the rest of the line.
+// Inside single quotes, all characters are literal.
+func parseSingleQuoted(s string, reader *bufio.Reader, lineNumber *int) (value, rest string, err error) {
+	var b strings.Builder
+	for {
+		if i := strings.IndexByte(s, '\''); i != -1 {
+			b.WriteString(s[:i])
+			return b.String(), s[i+1:], nil
+		}
+		b.WriteString(s + "\n")
+		if s, err = nextLine(reader, lineNumber); err != nil {
+			return "", "", fmt.Errorf("unterminated single-quoted value")
+		}
+	}
+}
+
+func nextLine(reader *bufio.Reader, lineNumber *int) (string, error) {
+	line, err := readLine(reader)
+	if line == "" && err != nil {
+		return "", err
+	}
+	*lineNumber++
+	return line, nil
+}
+
+func validEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
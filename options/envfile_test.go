@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var envFileTestVars = map[string]string{
+	"SERVICE_HOST":  "localhost",
+	"EMPTY":         "",
+	"QUOTES":        `say "hi" and 'bye'`,
+	"BACKSLASHES":   `C:\path\to\`,
+	"EXPANSION":     "$HOME ${HOME} $(id) `id`",
+	"MULTILINE":     "line 1\nline 2\n",
+	"SPACES":        "  a  b  ",
+	"EQUALS_HASH":   "a=b # not a comment",
+	"UNICODE":       "héllo wörld",
+	"ESCAPED_SLASH": `\"\$`,
+}
+
+func TestFormatEnvFile(t *testing.T) {
+	t.Run("sorted", func(t *testing.T) {
+		content := formatEnvFile(map[string]string{"C": "3", "A": "1", "B": "2"}, nil)
+		require.Equal(t, envFileHeader+"\nA=\"1\"\nB=\"2\"\nC=\"3\"\n", string(content))
+	})
+
+	t.Run("escaped", func(t *testing.T) {
+		content := formatEnvFile(map[string]string{"V": "a\"b\\c$d`e"}, nil)
+		require.Equal(t, envFileHeader+"\nV=\"a\\\"b\\\\c\\$d\\`e\"\n", string(content))
+	})
+
+	t.Run("sources", func(t *testing.T) {
+		content := formatEnvFile(map[string]string{"A": "1", "B": "2"}, map[string]string{"A": "config.a"})
+		require.Equal(t, envFileHeader+"\n# source: config.a\nA=\"1\"\nB=\"2\"\n", string(content))
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		envVars, err := parseEnvFile(formatEnvFile(envFileTestVars, nil))
+		require.NoError(t, err)
+		require.Equal(t, envFileTestVars, envVars)
+	})
+
+	t.Run("sourced by bash", func(t *testing.T) {
+		bash, err := exec.LookPath("bash")
+		if err != nil {
+			t.Skip("bash not found")
+		}
+
+		file := filepath.Join(t.TempDir(), "overrides.env")
+		require.NoError(t, os.WriteFile(file, formatEnvFile(envFileTestVars, nil), 0644))
+
+		for k, v := range envFileTestVars {
+			// print the value with a terminator to keep trailing newlines
+			out, err := exec.Command(bash, "-c", `source "$1" && printf '%s.' "${!2}"`, "-", file, k).Output()
+			require.NoError(t, err)
+			require.Equal(t, v, strings.TrimSuffix(string(out), "."), "Variable %s", k)
+		}
+	})
+}
+
+func TestParseEnvFile(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		envVars, err := parseEnvFile([]byte(`
+# comment
+export EXPORTED=1
+UNQUOTED=value # comment
+SINGLE='$HOME \n'
+DOUBLE="a \x \"b\" \
+c"
+MULTI="1
+2"
+`))
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"EXPORTED": "1",
+			"UNQUOTED": "value",
+			"SINGLE":   `$HOME \n`,
+			"DOUBLE":   `a \x "b" c`,
+			"MULTI":    "1\n2",
+		}, envVars)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, content := range []string{
+			"NO_ASSIGNMENT",
+			"1NVALID=name",
+			`UNTERMINATED="value`,
+			`UNTERMINATED='value`,
+			`TRAILING="value" garbage`,
+		} {
+			_, err := parseEnvFile([]byte(content))
+			require.Error(t, err, content)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/canonical/edgex-snap-hooks/v3/log"
+)
+
+type groupOptions struct {
+	Config *configOptions `json:"config"`
+}
+
+type configGroup struct {
+	name string
+	apps []string
+}
+
+// configGroupList holds the declared groups, in the order of declaration
+type configGroupList []configGroup
+
+// with returns a copy of the list with the group declared.
+// A group without apps is removed.
+func (l configGroupList) with(name string, apps []string) configGroupList {
+	// the caller may reuse the slice
+	apps = append([]string(nil), apps...)
+
+	var groups configGroupList
+	declared := false
+	for _, group := range l {
+		if group.name == name {
+			declared = true
+			if len(apps) == 0 {
+				continue
+			}
+			group.apps = apps
+		}
+		groups = append(groups, group)
+	}
+	if !declared && len(apps) != 0 {
+		groups = append(groups, configGroup{name, apps})
+	}
+	return groups
+}
+
+// of returns the names of the groups of the app, in the order of declaration
+func (l configGroupList) of(app string) []string {
+	var groups []string
+	for _, group := range l {
+		if contains(group.apps, app) {
+			groups = append(groups, group.name)
+		}
+	}
+	return groups
+}
+
+// members returns the apps of the group which are among the processed apps
+func (l configGroupList) members(name string, apps []string) []string {
+	var members []string
+	for _, group := range l {
+		if group.name != name {
+			continue
+		}
+		for _, app := range group.apps {
+			if contains(apps, app) {
+				members = append(members, app)
+			}
+		}
+	}
+	return members
+}
+
+func (l configGroupList) validate(groupConfigOptions map[string]groupOptions) error {
+	var declared []string
+	for _, group := range l {
+		declared = append(declared, group.name)
+	}
+
+	// make sure that set groups in options are declared
+	var names []string
+	for name := range groupConfigOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if groupConfigOptions[name].Config != nil && !contains(declared, name) {
+			return fmt.Errorf("unsupported group in group config option: %s. Supported groups are: %v",
+				name,
+				declared,
+			)
+		}
+	}
+	return nil
+}
+
+// Process the "groups.<group>.config.<my.env.var>" configuration
+//
+//	-> setting env var MY_ENV_VAR for all apps of the group
+func (cp *configProcessor) processGroupConfigOptions(options *snapOptions, services []string) error {
+	err := cp.groups.validate(options.Groups)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range cp.groups {
+		groupConfig := options.Groups[group.name]
+		if groupConfig.Config == nil {
+			// no config options for this group
+			continue
+		}
+
+		log.Debugf("Processing group: %s", group.name)
+		configuration, err := cp.getConfigMap(*groupConfig.Config)
+		if err != nil {
+			return err
+		}
+		scope := "groups." + group.name + ".config"
+		if err := cp.checkCollisions(scope, configuration); err != nil {
+			return err
+		}
+
+		for _, service := range cp.groups.members(group.name, services) {
+			for env, value := range configuration.values {
+				log.Debugf("Processing group config option for %s: %v=%v", service, env, value)
+				secret := cp.isSecret(configuration.source(env))
+				if err := cp.addEnvVar(service, scope+"."+configuration.source(env), env, value, secret); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
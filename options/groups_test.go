@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options_test
+
+import (
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigGroups(t *testing.T) {
+	const testService3 = "test-service3"
+
+	groups := []options.ProcessorOption{
+		options.WithConfigGroup("devices", testService, testService2),
+		options.WithConfigGroup("extra", testService2),
+	}
+	p, err := options.NewProcessor(groups...)
+	require.NoError(t, err)
+
+	t.Run("precedence", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config", map[string]interface{}{
+			"a": "global", "b": "global", "c": "global",
+		})
+		fake.SetConfig("groups.devices.config", map[string]interface{}{
+			"b": "devices", "c": "devices",
+		})
+		fake.SetConfig("groups.extra.config", map[string]interface{}{
+			"c": "extra",
+		})
+		fake.SetConfig("apps."+testService2+".config", map[string]interface{}{
+			"a": "app",
+		})
+
+		require.NoError(t, p.Process(testService, testService2, testService3))
+
+		require.Equal(t, "# Sys-gen env vars from snap options:\n"+
+			"# source: config.a\nA=\"global\"\n"+
+			"# source: groups.devices.config.b\nB=\"devices\"\n"+
+			"# source: groups.devices.config.c\nC=\"devices\"\n",
+			readFile(t, envFilePath(testService)))
+		require.Equal(t, "# Sys-gen env vars from snap options:\n"+
+			"# source: apps.test-service2.config.a\nA=\"app\"\n"+
+			"# source: groups.devices.config.b\nB=\"devices\"\n"+
+			"# source: groups.extra.config.c\nC=\"extra\"\n",
+			readFile(t, envFilePath(testService2)))
+		require.Equal(t, "# Sys-gen env vars from snap options:\n"+
+			"# source: config.a\nA=\"global\"\n"+
+			"# source: config.b\nB=\"global\"\n"+
+			"# source: config.c\nC=\"global\"\n",
+			readFile(t, envFilePath(testService3)))
+	})
+
+	t.Run("undeclared group", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("groups.unknown.config.a", "b")
+
+		err := p.Process(testService)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported group")
+	})
+
+	t.Run("schema requires", func(t *testing.T) {
+		p, err := options.NewProcessor(append(groups, options.WithSchema(options.Schema{
+			"tls-cert": {Requires: []string{"tls-key"}},
+			"tls-key":  {},
+		}))...)
+		require.NoError(t, err)
+
+		fake := installFake(t)
+		fake.SetConfig("groups.devices.config.tls-key", "key.pem")
+		fake.SetConfig("apps."+testService+".config.tls-cert", "cert.pem")
+		fake.SetConfig("apps."+testService3+".config.tls-cert", "cert.pem")
+
+		err = p.Process(testService, testService3)
+		var validationErr *options.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, []string{
+			"apps.test-service3.config.tls-cert: requires apps.test-service3.config.tls-key to be set",
+		}, validationErr.Problems)
+	})
+}
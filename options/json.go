@@ -17,15 +17,87 @@
 package options
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
+// ArrayStrategy defines how a JSON array in config options is converted to environment variables
+type ArrayStrategy int
+
+const (
+	// ArrayCommaSeparated joins the elements with commas, e.g. CLIENTS="a,b".
+	// The elements must be strings, bools, or numbers.
+	ArrayCommaSeparated ArrayStrategy = iota
+	// ArrayJSON serializes the array as JSON, e.g. CLIENTS="[\"a\",\"b\"]"
+	ArrayJSON
+	// ArrayIndexed sets one variable per element, e.g. CLIENTS_0="a" and CLIENTS_1="b".
+	// The index is appended as a child key, or as a segment if the config hierarchy
+	// is disabled.
+	ArrayIndexed
+)
+
+func (p *Processor) arrayStrategy(key string) ArrayStrategy {
+	if strategy, found := p.arrayStrategies[key]; found {
+		return strategy
+	}
+	return p.defaultArrayStrategy
+}
+
+// flatConfig holds the flattened config options of one scope
+type flatConfig struct {
+	// values maps the flattened keys to values
+	values map[string]string
+	// sources maps the flattened keys to the keys of the config options which set them.
+	// They differ for the elements of indexed arrays.
+	sources map[string]string
+	// collisions lists the flattened keys set by multiple config options
+	collisions []keyCollision
+
+	// hierarchy is true if the config hierarchy is enabled
+	hierarchy bool
+	// arrayStrategy returns the strategy for the array under the given key
+	arrayStrategy func(key string) ArrayStrategy
+}
+
+type keyCollision struct {
+	key              string
+	source, previous string
+}
+
+func newFlatConfig(hierarchy bool, arrayStrategy func(key string) ArrayStrategy) *flatConfig {
+	return &flatConfig{
+		values:        make(map[string]string),
+		sources:       make(map[string]string),
+		hierarchy:     hierarchy,
+		arrayStrategy: arrayStrategy,
+	}
+}
+
+// set sets the value of the flattened key
+func (c *flatConfig) set(key, source, value string) {
+	if previous, found := c.sources[key]; found && previous != source {
+		c.collisions = append(c.collisions, keyCollision{key, source, previous})
+	}
+	c.sources[key] = source
+	c.values[key] = value
+}
+
+// source returns the key of the config option which set the flattened key
+func (c *flatConfig) source(key string) string {
+	if source, found := c.sources[key]; found {
+		return source
+	}
+	return key
+}
+
 // p is the current prefix of the config key being processed (e.g. "service", "security.auth")
 // k is the key name of the current JSON object being processed
 // vJSON is the current object
-// flatConf is a map containing the configuration keys/values processed thus far
-func flattenConfigJSON(p string, k string, vJSON interface{}, flatConf map[string]string) error {
+// flatConf holds the configuration keys/values processed thus far
+func flattenConfigJSON(p string, k string, vJSON interface{}, flatConf *flatConfig) error {
 	var mk string
 
 	// top level keys don't include "env", so no separator needed
@@ -36,12 +108,15 @@ func flattenConfigJSON(p string, k string, vJSON interface{}, flatConf map[strin
 	}
 
 	switch t := vJSON.(type) {
+	case nil:
+		// snapd doesn't store null options, so only null elements
+		// of indexed arrays get here. They set no variable.
 	case string:
-		flatConf[mk] = t
+		flatConf.set(mk, mk, t)
 	case bool:
-		flatConf[mk] = strconv.FormatBool(t)
-	case float64:
-		flatConf[mk] = strconv.FormatFloat(t, 'f', -1, 64)
+		flatConf.set(mk, mk, strconv.FormatBool(t))
+	case json.Number:
+		flatConf.set(mk, mk, t.String())
 	case map[string]interface{}:
 
 		for k, v := range t {
@@ -50,8 +125,62 @@ func flattenConfigJSON(p string, k string, vJSON interface{}, flatConf map[strin
 				return err
 			}
 		}
+	case []interface{}:
+		return flattenConfigArray(mk, t, flatConf)
 	default:
 		return fmt.Errorf("internal error: invalid JSON configuration from snapd - prefix: %s key: %s obj: %v", p, k, t)
 	}
 	return nil
 }
+
+// flattenConfigArray converts the array under key mk based on the key's strategy
+func flattenConfigArray(mk string, array []interface{}, flatConf *flatConfig) error {
+	switch strategy := flatConf.arrayStrategy(mk); strategy {
+	case ArrayCommaSeparated:
+		elements := make([]string, len(array))
+		for i, v := range array {
+			switch e := v.(type) {
+			case string:
+				elements[i] = e
+			case bool:
+				elements[i] = strconv.FormatBool(e)
+			case json.Number:
+				elements[i] = e.String()
+			default:
+				return fmt.Errorf("unsupported element in array %s: %v. Only strings, bools, and numbers can be comma-separated", mk, v)
+			}
+			if strings.Contains(elements[i], ",") {
+				return fmt.Errorf("unsupported element in array %s: %q. Elements must not contain commas", mk, elements[i])
+			}
+		}
+		flatConf.set(mk, mk, strings.Join(elements, ","))
+	case ArrayJSON:
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(array); err != nil {
+			return fmt.Errorf("error encoding array %s: %s", mk, err)
+		}
+		flatConf.set(mk, mk, strings.TrimSuffix(buffer.String(), "\n"))
+	case ArrayIndexed:
+		elements := newFlatConfig(flatConf.hierarchy, flatConf.arrayStrategy)
+		for i, v := range array {
+			var err error
+			if flatConf.hierarchy {
+				err = flattenConfigJSON(mk, strconv.Itoa(i), v, elements)
+			} else {
+				// append the index as a segment, since dots aren't allowed
+				err = flattenConfigJSON("", mk+"-"+strconv.Itoa(i), v, elements)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for k, v := range elements.values {
+			flatConf.set(k, mk, v)
+		}
+	default:
+		return fmt.Errorf("unsupported array strategy for %s: %d", mk, strategy)
+	}
+	return nil
+}
@@ -17,27 +17,48 @@
 package options
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/canonical/edgex-snap-hooks/v3/env"
 	"github.com/canonical/edgex-snap-hooks/v3/log"
 	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
 )
 
 type configOptions map[string]interface{}
 
+// UnmarshalJSON decodes numbers as json.Number, to keep their exact
+// representation, e.g. of integers above 2^53
+func (c *configOptions) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var m map[string]interface{}
+	if err := decoder.Decode(&m); err != nil {
+		return err
+	}
+	*c = m
+	return nil
+}
+
 type appOptions struct {
 	Config    *configOptions `json:"config"`
 	Autostart *bool          `json:"autostart"`
+	Profile   interface{}    `json:"profile"`
 }
 
 type snapOptions struct {
-	Apps   map[string]appOptions `json:"apps"`
-	Config *configOptions        `json:"config"`
+	Apps   map[string]appOptions   `json:"apps"`
+	Groups map[string]groupOptions `json:"groups"`
+	Config *configOptions          `json:"config"`
+	// Profile is the global profile option, see WithProfiles
+	Profile interface{} `json:"profile"`
 }
 
-func getConfigMap(config configOptions) (map[string]string, error) {
-	result := make(map[string]string)
+func (p *Processor) getConfigMap(config configOptions) (*flatConfig, error) {
+	result := newFlatConfig(p.hierarchy, p.arrayStrategy)
 
 	for env, value := range config {
 		if err := flattenConfigJSON("", env, value, result); err != nil {
@@ -47,34 +68,60 @@ func getConfigMap(config configOptions) (map[string]string, error) {
 	return result, nil
 }
 
-// Process the "config.<my.env.var>" configuration
-//
-//	-> setting env variable for all apps (e.g. DEBUG=true, SERVICE_SERVERBINDADDRESS=0.0.0.0)
-func (cp *configProcessor) processGlobalConfigOptions(services []string) error {
+// getSnapOptions reads the global, group, and app-specific config options.
+// The group options are only read if requested, i.e. if groups have been declared.
+// Likewise, the global profile option is only read if profiles have been enabled.
+func getSnapOptions(readGroups, readProfile bool) (*snapOptions, error) {
 	var options snapOptions
 
-	jsonString, err := snapctl.Get("config").Document().Run()
-	if err != nil {
-		return err
+	err := snapctl.GetInto(&options.Config, "config")
+	if err != nil && !snapctl.IsUnset(err) {
+		return nil, fmt.Errorf("error reading 'config' option: %s", err)
 	}
-	err = json.Unmarshal([]byte(jsonString), &options)
-	if err != nil {
-		return err
+
+	err = snapctl.GetInto(&options.Apps, "apps")
+	if err != nil && !snapctl.IsUnset(err) {
+		return nil, fmt.Errorf("error reading 'apps' option: %s", err)
 	}
 
+	if readGroups {
+		err = snapctl.GetInto(&options.Groups, "groups")
+		if err != nil && !snapctl.IsUnset(err) {
+			return nil, fmt.Errorf("error reading 'groups' option: %s", err)
+		}
+	}
+
+	if readProfile {
+		err = snapctl.GetInto(&options.Profile, "profile")
+		if err != nil && !snapctl.IsUnset(err) {
+			return nil, fmt.Errorf("error reading 'profile' option: %s", err)
+		}
+	}
+
+	return &options, nil
+}
+
+// Process the "config.<my.env.var>" configuration
+//
+//	-> setting env variable for all apps (e.g. DEBUG=true, SERVICE_SERVERBINDADDRESS=0.0.0.0)
+func (cp *configProcessor) processGlobalConfigOptions(options *snapOptions, services []string) error {
 	if options.Config == nil {
 		log.Debugf("No global config options")
 		return nil
 	}
 
-	configuration, err := getConfigMap(*options.Config)
+	configuration, err := cp.getConfigMap(*options.Config)
 	if err != nil {
 		return err
 	}
+	if err := cp.checkCollisions("config", configuration); err != nil {
+		return err
+	}
 	for _, service := range services {
-		for env, value := range configuration {
+		for env, value := range configuration.values {
 			log.Debugf("Processing globally set env var for %s: %v=%v", service, env, value)
-			if err := cp.addEnvVar(service, env, value); err != nil {
+			secret := cp.isSecret(configuration.source(env))
+			if err := cp.addEnvVar(service, "config."+configuration.source(env), env, value, secret); err != nil {
 				return err
 			}
 		}
@@ -103,20 +150,8 @@ func validateAppConfigOptions(appConfigOptions map[string]appOptions, expectedSe
 // Process the "apps.<app>.config.<my.env.var>" configuration
 //
 //	-> setting env var MY_ENV_VAR for an app
-func (cp *configProcessor) processAppConfigOptions(services []string) error {
-	var options snapOptions
-
-	// get the 'apps' json structure
-	jsonString, err := snapctl.Get("apps").Document().Run()
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal([]byte(jsonString), &options)
-	if err != nil {
-		return err
-	}
-
-	err = validateAppConfigOptions(options.Apps, services)
+func (cp *configProcessor) processAppConfigOptions(options *snapOptions, services []string) error {
+	err := validateAppConfigOptions(options.Apps, services)
 	if err != nil {
 		return err
 	}
@@ -135,23 +170,29 @@ func (cp *configProcessor) processAppConfigOptions(services []string) error {
 		}
 
 		log.Debugf("Processing config: %v", appConfig.Config)
-		configuration, err := getConfigMap(*appConfig.Config)
+		configuration, err := cp.getConfigMap(*appConfig.Config)
 
 		log.Debugf("Processing flattened config: %v", configuration)
 		if err != nil {
 			return err
 		}
-		for env, value := range configuration {
+		scope := "apps." + service + ".config"
+		if err := cp.checkCollisions(scope, configuration); err != nil {
+			return err
+		}
+		for env, value := range configuration.values {
 			log.Debugf("Processing config option for %s: %v=%v", service, env, value)
-			if err := cp.addEnvVar(service, env, value); err != nil {
+			secret := cp.isSecret(configuration.source(env))
+			if err := cp.addEnvVar(service, scope+"."+configuration.source(env), env, value, secret); err != nil {
 				return err
 			}
 		}
-
 	}
 	return nil
 }
 
+// The package-level settings used by ProcessConfig.
+// A Processor allows different settings in the same process.
 var (
 	// Snapd uses dots for hierarchy and hyphens as segment separators
 	// These separators map to another character for environment variable names
@@ -162,12 +203,16 @@ var (
 
 // SetSegmentSeparator sets the separator used to replace hyphens in config.<x-y>
 // Default is _
+//
+// Deprecated: Use NewProcessor with WithSegmentSeparator.
 func SetSegmentSeparator(sep string) {
 	envSegmentSeparator = sep
 }
 
 // SetHierarchySeparator sets the separator used to replace dots in config.<x.y>
 // Default is _
+//
+// Deprecated: Use NewProcessor with WithHierarchySeparator.
 func SetHierarchySeparator(sep string) {
 	envHierarchySeparator = sep
 }
@@ -175,6 +220,8 @@ func SetHierarchySeparator(sep string) {
 // EnableConfigHierarchy is to allow config options such as config.<x.y> with
 //
 //	dots as the config key
+//
+// Deprecated: Use NewProcessor with WithConfigHierarchy.
 func EnableConfigHierarchy() {
 	configHierarchy = true
 }
@@ -192,29 +239,60 @@ func EnableConfigHierarchy() {
 // b) snap set edgex-snap-name config.<my.env.var>
 //
 //	-> sets env variable for all apps (e.g. DEBUG=true, SERVICE_SERVERBINDADDRESS=0.0.0.0)
+//
+// App settings take precedence over global settings.
+//
+// Options of the same scope which map to the same environment variable,
+// e.g. config.a-b and config.a.b, are rejected with an error naming both.
+//
+// ProcessConfig uses the package-level settings. Use a Processor for
+// config groups, schema validation, key registries, array strategies,
+// and restarting the services whose environment has changed.
 func ProcessConfig(apps ...string) error {
-	// uncomment to enable snap debugging
-	// snapctl.Set("debug", "true")
+	return globalProcessor().Process(apps...)
+}
 
-	if len(apps) == 0 {
-		return fmt.Errorf("empty apps list")
-	}
+// ProcessConfigChanges is similar to ProcessConfig, but returns the sorted names
+// of the apps whose environment variables have changed.
+// Env files are only written when their content has changed.
+// It never restarts services.
+func ProcessConfigChanges(apps ...string) (changed []string, err error) {
+	return globalProcessor().ProcessChanges(apps...)
+}
 
-	cp := newConfigProcessor(apps, configHierarchy, envHierarchySeparator, envSegmentSeparator)
+// PlanConfig is a dry run of ProcessConfig.
+// It processes the config options and returns the planned changes to the env files
+// without writing them.
+func PlanConfig(apps ...string) (*ConfigPlan, error) {
+	return globalProcessor().Plan(apps...)
+}
 
-	// process app-specific options
-	if err := cp.processGlobalConfigOptions(apps); err != nil {
-		return err
+// restartActiveApps restarts the services of the apps which are active.
+// Inactive services pick up the changes when they are started.
+func restartActiveApps(apps []string) error {
+	var services []string
+	for _, app := range apps {
+		services = append(services, env.SnapName+"."+app)
 	}
 
-	// process global options
-	if err := cp.processAppConfigOptions(apps); err != nil {
-		return err
+	status, err := snapctl.Services(services...).Run()
+	if err != nil {
+		return fmt.Errorf("error getting status of services: %s", err)
 	}
 
-	if err := cp.writeEnvFiles(); err != nil {
-		return err
+	var active []string
+	for _, service := range services {
+		if status[service].Active {
+			active = append(active, service)
+		}
+	}
+	if len(active) == 0 {
+		return nil
 	}
 
+	log.Infof("Restarting services with changed environment: %s", strings.Join(active, ", "))
+	if err := snapctl.Restart(active...).Run(); err != nil {
+		return fmt.Errorf("error restarting services: %s", err)
+	}
 	return nil
 }
@@ -17,6 +17,7 @@
 package options_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -28,6 +29,7 @@ import (
 	"github.com/canonical/edgex-snap-hooks/v3/log"
 	"github.com/canonical/edgex-snap-hooks/v3/options"
 	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -209,8 +211,262 @@ func TestProcessConfig(t *testing.T) {
 	})
 }
 
+func TestProcessConfigChanges(t *testing.T) {
+	fake := installFake(t)
+	fake.SetConfig("apps."+testService+".config.x-y", "value")
+
+	t.Run("first write", func(t *testing.T) {
+		changed, err := options.ProcessConfigChanges(testService, testService2)
+		require.NoError(t, err)
+		require.Equal(t, []string{testService}, changed)
+		require.False(t, fileExists(t, envFilePath(testService2)), "Env file should not exist.")
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		info, err := os.Stat(envFilePath(testService))
+		require.NoError(t, err)
+
+		changed, err := options.ProcessConfigChanges(testService, testService2)
+		require.NoError(t, err)
+		require.Empty(t, changed)
+
+		// a write would replace the file
+		newInfo, err := os.Stat(envFilePath(testService))
+		require.NoError(t, err)
+		require.True(t, os.SameFile(info, newInfo), "Env file should not be replaced.")
+	})
+
+	t.Run("comments only", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(envFilePath(testService),
+			[]byte("# marker\nX_Y=\"value\"\n"), 0644))
+
+		changed, err := options.ProcessConfigChanges(testService, testService2)
+		require.NoError(t, err)
+		require.Empty(t, changed)
+		require.Error(t, fileContains(t, envFilePath(testService), "# marker"))
+		require.NoError(t, fileContains(t, envFilePath(testService), "# source: apps."+testService+".config.x-y"))
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		fake.SetConfig("config.debug", true)
+
+		changed, err := options.ProcessConfigChanges(testService, testService2)
+		require.NoError(t, err)
+		require.Equal(t, []string{testService, testService2}, changed)
+		require.NoError(t, fileContains(t, envFilePath(testService2), `DEBUG="true"`))
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		fake.SetConfig("config", nil)
+
+		changed, err := options.ProcessConfigChanges(testService, testService2)
+		require.NoError(t, err)
+		require.Equal(t, []string{testService, testService2}, changed)
+		require.False(t, fileExists(t, envFilePath(testService2)), "Env file should not exist.")
+		require.NoError(t, fileContains(t, envFilePath(testService), `X_Y="value"`))
+	})
+
+	t.Run("restart on change", func(t *testing.T) {
+		p, err := options.NewProcessor(options.WithRestartOnChange())
+		require.NoError(t, err)
+
+		fake.AddService(env.SnapName+"."+testService, true, true)
+		fake.AddService(env.SnapName+"."+testService2, true, false)
+		fake.SetConfig("config.debug", false)
+		fake.ResetCalls()
+
+		require.NoError(t, p.Process(testService, testService2))
+		require.Contains(t, fake.Calls(), snapctltest.Call{
+			Subcommand: "restart",
+			Args:       []string{env.SnapName + "." + testService},
+		})
+
+		// nothing to restart
+		fake.ResetCalls()
+		require.NoError(t, p.Process(testService, testService2))
+		for _, call := range fake.Calls() {
+			require.NotEqual(t, "restart", call.Subcommand)
+		}
+	})
+}
+
+func TestProcessConfigArrays(t *testing.T) {
+	t.Run("strategies", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config", map[string]interface{}{
+			"comma":   []interface{}{"a", "b", 1, true},
+			"json":    []interface{}{"a<b", 1.5, nil},
+			"indexed": []interface{}{"a", "b"},
+			"empty":   []interface{}{},
+		})
+
+		p, err := options.NewProcessor(
+			options.WithArrayStrategy("json", options.ArrayJSON),
+			options.WithArrayStrategy("indexed", options.ArrayIndexed),
+		)
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+		for _, line := range []string{
+			`COMMA="a,b,1,true"`,
+			`JSON="[\"a<b\",1.5,null]"`,
+			`INDEXED_0="a"`,
+			`INDEXED_1="b"`,
+			`EMPTY=""`,
+		} {
+			require.NoError(t, fileContains(t, envFilePath(testService), line),
+				"File content:\n%s", readFile(t, envFilePath(testService)))
+		}
+
+		// elements of indexed arrays are set by the array option
+		plan, err := p.Plan(testService)
+		require.NoError(t, err)
+		source, _ := plan.Source(testService, "INDEXED_1")
+		require.Equal(t, "config.indexed", source)
+	})
+
+	t.Run("reject objects in comma-separated arrays", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.comma", []interface{}{map[string]interface{}{"a": "b"}})
+		require.Error(t, options.ProcessConfig(testService))
+
+		fake.SetConfig("config.comma", []interface{}{"a,b"})
+		require.Error(t, options.ProcessConfig(testService))
+	})
+
+	t.Run("default strategy", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.clients", []interface{}{"a", "b"})
+
+		p, err := options.NewProcessor(options.WithDefaultArrayStrategy(options.ArrayJSON))
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+		require.NoError(t, fileContains(t, envFilePath(testService), `CLIENTS="[\"a\",\"b\"]"`),
+			"File content:\n%s", readFile(t, envFilePath(testService)))
+	})
+}
+
+func TestProcessConfigNull(t *testing.T) {
+	fake := installFake(t)
+	fake.SetConfig("config", map[string]interface{}{
+		"debug": true,
+		"host":  "localhost",
+		"port":  nil,
+	})
+	fake.SetConfig("apps."+testService+".config", map[string]interface{}{
+		"debug": nil,
+	})
+
+	require.NoError(t, options.ProcessConfig(testService, testService2))
+
+	// snapd drops the nulls, so the app keeps the global options
+	for _, app := range []string{testService, testService2} {
+		require.Equal(t, "# Sys-gen env vars from snap options:\n"+
+			"# source: config.debug\nDEBUG=\"true\"\n"+
+			"# source: config.host\nHOST=\"localhost\"\n",
+			readFile(t, envFilePath(app)))
+	}
+
+	// null elements of indexed arrays set no variable
+	fake.SetConfig("config.hosts", []interface{}{"a", nil, "b"})
+	p, err := options.NewProcessor(options.WithArrayStrategy("hosts", options.ArrayIndexed))
+	require.NoError(t, err)
+	plan, err := p.Plan(testService)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"DEBUG":   "true",
+		"HOST":    "localhost",
+		"HOSTS_0": "a",
+		"HOSTS_2": "b",
+	}, plan.Apps[0].Planned)
+}
+
+func TestProcessConfigNumbers(t *testing.T) {
+	min := 0.0
+	p, err := options.NewProcessor(options.WithSchema(options.Schema{
+		"device-id": {Type: options.TypeInt, Min: &min},
+		"ratio":     {Type: options.TypeNumber},
+		"ids":       {Type: options.TypeArray},
+	}))
+	require.NoError(t, err)
+
+	fake := installFake(t)
+	fake.SetConfig("config", map[string]interface{}{
+		"device-id": json.Number("9007199254740993"),
+		"ratio":     json.Number("0.1"),
+		"ids":       []interface{}{json.Number("1700000000123456789"), 2},
+	})
+
+	require.NoError(t, p.Process(testService))
+	for _, line := range []string{
+		`DEVICE_ID="9007199254740993"`,
+		`RATIO="0.1"`,
+		`IDS="1700000000123456789,2"`,
+	} {
+		require.NoError(t, fileContains(t, envFilePath(testService), line),
+			"File content:\n%s", readFile(t, envFilePath(testService)))
+	}
+}
+
+func TestProcessConfigCollisions(t *testing.T) {
+	t.Run("same scope", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("apps."+testService+".config", map[string]interface{}{
+			"a-b": "1",
+			"ab":  "2",
+		})
+
+		p, err := options.NewProcessor(options.WithSegmentSeparator(""))
+		require.NoError(t, err)
+		err = p.Process(testService)
+		require.EqualError(t, err, "config options apps.test-service.config.a-b and "+
+			"apps.test-service.config.ab both map to environment variable AB")
+		require.False(t, fileExists(t, envFilePath(testService)), "Env file should not exist.")
+	})
+
+	t.Run("indexed array", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config", map[string]interface{}{
+			"hosts":   []interface{}{"a"},
+			"hosts-0": "b",
+		})
+
+		p, err := options.NewProcessor(options.WithArrayStrategy("hosts", options.ArrayIndexed))
+		require.NoError(t, err)
+		err = p.Process(testService)
+		require.EqualError(t, err, "config options config.hosts and "+
+			"config.hosts-0 both map to environment variable HOSTS_0")
+	})
+
+	t.Run("different scopes", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.a-b", "1")
+		fake.SetConfig("apps."+testService+".config.a-b", "2")
+
+		require.NoError(t, options.ProcessConfig(testService))
+		require.NoError(t, fileContains(t, envFilePath(testService), `A_B="2"`),
+			"File content:\n%s", readFile(t, envFilePath(testService)))
+	})
+}
+
 // utility testing functions
 
+// installFake sets up the snapctl fake and a temporary snap environment
+func installFake(t *testing.T) *snapctltest.Fake {
+	fake := snapctltest.Install(t)
+
+	snap, snapName, snapData := env.Snap, env.SnapName, env.SnapData
+	env.Snap, env.SnapName, env.SnapData = t.TempDir(), "edgex-snap-hooks", t.TempDir()
+	t.Cleanup(func() {
+		env.Snap, env.SnapName, env.SnapData = snap, snapName, snapData
+	})
+
+	return fake
+}
+
+func envFilePath(app string) string {
+	return path.Join(env.SnapData, "config", app, "overrides.env")
+}
+
 func fileExists(t *testing.T, file string) bool {
 	_, err := os.Stat(file)
 	if err == nil {
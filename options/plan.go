@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConfigPlan describes the changes that ProcessConfig would make to the env files
+type ConfigPlan struct {
+	// Apps holds the plans of all processed apps, sorted by app name
+	Apps []AppEnvPlan
+
+	// secretOptions are the secret options consumed by the plan
+	secretOptions []string
+}
+
+// AppEnvPlan describes the changes to the env file of one app
+type AppEnvPlan struct {
+	App string
+	// File is the path of the env file
+	File string
+	// Current holds the environment variables in the existing env file
+	Current map[string]string
+	// Planned holds the environment variables after processing.
+	// The env file gets removed if there are none.
+	Planned map[string]string
+	// Sources maps the planned environment variables, including secrets,
+	// to the snap options which set them, e.g. config.service-port or
+	// apps.<app>.config.service-port
+	Sources map[string]string
+	// Profile is the selected profile, if profiles are enabled for the app
+	Profile string
+	// SecretFile is the path of the secrets file, see WithSecrets
+	SecretFile string
+	// CurrentSecrets holds the environment variables in the existing secrets file
+	CurrentSecrets map[string]string
+	// PlannedSecrets holds the secret environment variables after processing.
+	// The secrets file gets removed if there are none.
+	PlannedSecrets map[string]string
+
+	// content is the content of the existing env file
+	content []byte
+	// invalid is true if the existing env file could not be read
+	invalid bool
+	// secretContent is the content of the existing secrets file
+	secretContent []byte
+	// secretInvalid is true if the existing secrets file could not be read
+	secretInvalid bool
+}
+
+// needsWrite returns true if the env file needs to be written or removed,
+// including when only its comments differ
+func (p AppEnvPlan) needsWrite() bool {
+	return needsWrite(p.content, p.invalid, p.Current, p.Planned, p.Sources)
+}
+
+// needsSecretWrite is similar to needsWrite, for the secrets file
+func (p AppEnvPlan) needsSecretWrite() bool {
+	return needsWrite(p.secretContent, p.secretInvalid, p.CurrentSecrets, p.PlannedSecrets, p.Sources)
+}
+
+func needsWrite(content []byte, invalid bool, current, planned, sources map[string]string) bool {
+	if invalid || !equalEnvVars(current, planned) {
+		return true
+	}
+	if len(planned) == 0 {
+		return content != nil
+	}
+	return !bytes.Equal(content, formatEnvFile(planned, sources))
+}
+
+// Changed returns true if the env file or secrets file needs to be written or removed
+func (p AppEnvPlan) Changed() bool {
+	return p.invalid || !equalEnvVars(p.Current, p.Planned) ||
+		p.secretInvalid || !equalEnvVars(p.CurrentSecrets, p.PlannedSecrets)
+}
+
+// Changed returns the names of the apps whose env files need to be written or removed
+func (p ConfigPlan) Changed() []string {
+	var apps []string
+	for _, app := range p.Apps {
+		if app.Changed() {
+			apps = append(apps, app.App)
+		}
+	}
+	return apps
+}
+
+// Source returns the snap option which sets the environment variable of the app
+func (p ConfigPlan) Source(app, name string) (option string, found bool) {
+	for _, appPlan := range p.Apps {
+		if appPlan.App == app {
+			option, found = appPlan.Sources[name]
+			return option, found
+		}
+	}
+	return "", false
+}
+
+// String returns the changes to the env files as a unified diff.
+// The values of secrets are redacted.
+func (p ConfigPlan) String() string {
+	var b strings.Builder
+	for _, app := range p.Apps {
+		if app.invalid || !equalEnvVars(app.Current, app.Planned) {
+			writeDiff(&b, app.File, app.invalid, app.Current, app.Planned, false)
+		}
+		if app.secretInvalid || !equalEnvVars(app.CurrentSecrets, app.PlannedSecrets) {
+			writeDiff(&b, app.SecretFile, app.secretInvalid, app.CurrentSecrets, app.PlannedSecrets, true)
+		}
+	}
+
+	if b.Len() == 0 {
+		return "No changes to env files\n"
+	}
+	return b.String()
+}
+
+// writeDiff writes the changes to one file as a unified diff
+func writeDiff(b *strings.Builder, file string, invalid bool, current, planned map[string]string, redact bool) {
+	from, to := file, file
+	if len(current) == 0 && !invalid {
+		from = "/dev/null"
+	}
+	if len(planned) == 0 {
+		to = "/dev/null"
+	}
+	fmt.Fprintf(b, "--- %s\n+++ %s\n", from, to)
+
+	names := make(map[string]bool)
+	for k := range current {
+		names[k] = true
+	}
+	for k := range planned {
+		names[k] = true
+	}
+	var sorted []string
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	format := func(name, value string) string {
+		if redact {
+			return name + "=***"
+		}
+		return formatEnvVar(name, value)
+	}
+
+	for _, k := range sorted {
+		currentValue, inCurrent := current[k]
+		plannedValue, inPlanned := planned[k]
+		switch {
+		case inCurrent && inPlanned && currentValue == plannedValue:
+			fmt.Fprintf(b, " %s\n", format(k, currentValue))
+		default:
+			if inCurrent {
+				fmt.Fprintf(b, "-%s\n", format(k, currentValue))
+			}
+			if inPlanned {
+				fmt.Fprintf(b, "+%s\n", format(k, plannedValue))
+			}
+		}
+	}
+}
+
+// AutostartPlan describes the services that ProcessAutostart would start or stop.
+// It is also the report of the changes made by ProcessAutostartChanges.
+type AutostartPlan struct {
+	// Start lists the services to start and enable, in order
+	Start []string
+	// Stop lists the services to stop and disable, in order
+	Stop []string
+	// Unchanged lists the services which are already in the desired state, sorted
+	Unchanged []string
+	// Deferred lists the services which are not started until
+	// the plugs they require are connected, in order
+	Deferred []string
+}
+
+// String returns a human-readable summary of the plan
+func (p AutostartPlan) String() string {
+	if len(p.Start) == 0 && len(p.Stop) == 0 && len(p.Deferred) == 0 {
+		return "No services to start or stop\n"
+	}
+
+	var b strings.Builder
+	if len(p.Start) != 0 {
+		fmt.Fprintf(&b, "Start and enable: %s\n", strings.Join(p.Start, ", "))
+	}
+	if len(p.Stop) != 0 {
+		fmt.Fprintf(&b, "Stop and disable: %s\n", strings.Join(p.Stop, ", "))
+	}
+	if len(p.Deferred) != 0 {
+		fmt.Fprintf(&b, "Start once plugs are connected: %s\n", strings.Join(p.Deferred, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanConfig(t *testing.T) {
+	fake := installFake(t)
+	fake.SetConfig("apps."+testService+".config", map[string]interface{}{
+		"x-y":   "new",
+		"debug": true,
+	})
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(envFilePath(testService)), 0755))
+	require.NoError(t, os.WriteFile(envFilePath(testService),
+		[]byte("DEBUG=\"true\"\nX_Y=\"old\"\nREMOVED=\"1\"\n"), 0644))
+
+	plan, err := options.PlanConfig(testService, testService2)
+	require.NoError(t, err)
+	require.Equal(t, []string{testService}, plan.Changed())
+	require.Equal(t, map[string]string{"DEBUG": "true", "X_Y": "new"}, plan.Apps[0].Planned)
+
+	source, found := plan.Source(testService, "X_Y")
+	require.True(t, found)
+	require.Equal(t, "apps."+testService+".config.x-y", source)
+	_, found = plan.Source(testService, "REMOVED")
+	require.False(t, found)
+	for _, call := range fake.Calls() {
+		require.Equal(t, "get", call.Subcommand, "Only the config should be read.")
+	}
+
+	require.Equal(t, "--- "+envFilePath(testService)+"\n"+
+		"+++ "+envFilePath(testService)+"\n"+
+		" DEBUG=\"true\"\n"+
+		"-REMOVED=\"1\"\n"+
+		"-X_Y=\"old\"\n"+
+		"+X_Y=\"new\"\n", plan.String())
+
+	// nothing is written
+	require.NoError(t, fileContains(t, envFilePath(testService), `X_Y="old"`))
+	require.False(t, fileExists(t, envFilePath(testService2)), "Env file should not exist.")
+
+	t.Run("no changes", func(t *testing.T) {
+		_, err := options.ProcessConfigChanges(testService, testService2)
+		require.NoError(t, err)
+
+		plan, err := options.PlanConfig(testService, testService2)
+		require.NoError(t, err)
+		require.Empty(t, plan.Changed())
+		require.Equal(t, "No changes to env files\n", plan.String())
+	})
+}
+
+func TestPlanAutostart(t *testing.T) {
+	fake := installFake(t)
+	fake.AddService(mockService, false, false)
+	fake.AddService(mockService2, true, true)
+	fake.SetConfig("autostart", true)
+	fake.SetConfig("apps."+mockApp2+".autostart", false)
+
+	plan, err := options.PlanAutostart(mockApp, mockApp2)
+	require.NoError(t, err)
+	require.Equal(t, &options.AutostartPlan{
+		Start: []string{mockService},
+		Stop:  []string{mockService2},
+	}, plan)
+	require.Equal(t, "Start and enable: "+mockService+"\nStop and disable: "+mockService2+"\n", plan.String())
+
+	for _, call := range fake.Calls() {
+		require.NotContains(t, []string{"start", "stop"}, call.Subcommand)
+	}
+}
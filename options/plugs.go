@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/canonical/edgex-snap-hooks/v3/env"
+	"github.com/canonical/edgex-snap-hooks/v3/log"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+)
+
+// WithRequiredPlugs declares the plugs which must be connected for the app to start,
+// e.g. a serial-port or content plug.
+// ProcessAutostart defers starting the app, and the apps which depend on it,
+// until all its required plugs are connected.
+// Use ProcessConnectPlug and ProcessDisconnectPlug in the plug hooks
+// to start or stop the app when the connection changes.
+func WithRequiredPlugs(app string, plugs ...string) ProcessorOption {
+	return func(p *Processor) error {
+		for _, plug := range plugs {
+			if plug == "" || strings.Contains(plug, " ") {
+				return fmt.Errorf("invalid plug name for app %s: '%s'", app, plug)
+			}
+			if !contains(p.requiredPlugs[app], plug) {
+				p.requiredPlugs[app] = append(p.requiredPlugs[app], plug)
+			}
+		}
+		return nil
+	}
+}
+
+// ProcessConnectPlug starts and enables the apps which require the plug,
+// as well as the apps which depend on them, if their autostart option is true.
+// It is meant to be called from the connect-plug-<plug> hook,
+// and considers the plug connected.
+// It returns the services which have been started or stopped.
+func (p *Processor) ProcessConnectPlug(plug string) (*AutostartPlan, error) {
+	apps := p.gatedApps(plug)
+	if len(apps) == 0 {
+		log.Debugf("No apps require plug %s", plug)
+		return &AutostartPlan{}, nil
+	}
+
+	plugs := newPlugStates()
+	plugs.connected[plug] = true
+
+	plan, err := p.planAutostart(apps, plugs)
+	if err != nil {
+		return nil, err
+	}
+	return plan, runAutostart(plan)
+}
+
+// ProcessDisconnectPlug stops and disables the apps which require the plug.
+// The apps which depend on them are also stopped and disabled if WithAutostartCascade is set,
+// otherwise a warning is logged.
+// It is meant to be called from the disconnect-plug-<plug> hook.
+// The apps start again via ProcessConnectPlug, if their autostart option is true.
+// It returns the services which have been stopped.
+func (p *Processor) ProcessDisconnectPlug(plug string) (*AutostartPlan, error) {
+	stop := make(map[string]bool)
+	var apps []string
+	for app, plugs := range p.requiredPlugs {
+		if contains(plugs, plug) {
+			apps = append(apps, app)
+			stop[app] = true
+		}
+	}
+	sort.Strings(apps)
+	if len(apps) == 0 {
+		log.Debugf("No apps require plug %s", plug)
+		return &AutostartPlan{}, nil
+	}
+
+	for _, app := range apps {
+		log.Infof("%s will stop and disable, since plug %s is disconnected!", app, plug)
+		for _, dependent := range p.autostartDeps.dependents(app) {
+			if stop[dependent] {
+				continue
+			}
+			if p.autostartCascade {
+				log.Warnf("%s will stop and disable, since it depends on %s!", dependent, app)
+				stop[dependent] = true
+			} else {
+				log.Warnf("%s depends on %s, which will stop and disable!", dependent, app)
+			}
+		}
+	}
+
+	var desired AutostartPlan
+	stopOrder := p.autostartOrder(apps, stop)
+	for i := len(stopOrder) - 1; i >= 0; i-- {
+		desired.Stop = append(desired.Stop, env.SnapName+"."+stopOrder[i])
+	}
+
+	plan, err := reconcileAutostart(desired)
+	if err != nil {
+		return nil, err
+	}
+	return plan, runAutostart(plan)
+}
+
+// gatedApps returns the apps which require the plug,
+// and the apps which depend on them, sorted
+func (p *Processor) gatedApps(plug string) []string {
+	gated := make(map[string]bool)
+	for app, plugs := range p.requiredPlugs {
+		if contains(plugs, plug) {
+			gated[app] = true
+			for _, dependent := range p.autostartDeps.dependents(app) {
+				gated[dependent] = true
+			}
+		}
+	}
+
+	var apps []string
+	for app := range gated {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+// plugStates caches the connection states of plugs
+type plugStates struct {
+	connected map[string]bool
+}
+
+func newPlugStates() *plugStates {
+	return &plugStates{connected: make(map[string]bool)}
+}
+
+// disconnected returns the plugs which are not connected
+func (s *plugStates) disconnected(plugs []string) ([]string, error) {
+	var disconnected []string
+	for _, plug := range plugs {
+		connected, found := s.connected[plug]
+		if !found {
+			var err error
+			connected, err = snapctl.IsConnected(plug).Run()
+			if err != nil {
+				return nil, fmt.Errorf("error checking connection of plug %s: %s", plug, err)
+			}
+			s.connected[plug] = connected
+		}
+		if !connected {
+			disconnected = append(disconnected, plug)
+		}
+	}
+	return disconnected, nil
+}
+
+// describePlugs returns e.g. "plug x is" or "plugs x, y are"
+func describePlugs(plugs []string) string {
+	if len(plugs) == 1 {
+		return "plug " + plugs[0] + " is"
+	}
+	return "plugs " + strings.Join(plugs, ", ") + " are"
+}
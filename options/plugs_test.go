@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options_test
+
+import (
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredPlugs(t *testing.T) {
+	const (
+		deviceModbus = "device-modbus"
+		serialPort   = "serial-port"
+	)
+	deviceService := "edgex-snap-hooks." + deviceModbus
+
+	newProcessor := func(t *testing.T) *options.Processor {
+		p, err := options.NewProcessor(
+			options.WithRequiredPlugs(deviceModbus, serialPort),
+			options.WithAutostartDependency(mockApp, deviceModbus),
+		)
+		require.NoError(t, err)
+		return p
+	}
+
+	t.Run("deferred until connected", func(t *testing.T) {
+		fake := installFake(t)
+		fake.AddService(deviceService, false, false)
+		fake.AddService(mockService, false, false)
+		fake.AddService(mockService2, false, false)
+		fake.SetConfig("autostart", true)
+
+		p := newProcessor(t)
+		plan, err := p.ProcessAutostartChanges(deviceModbus, mockApp, mockApp2)
+		require.NoError(t, err)
+		require.Equal(t, []string{mockService2}, plan.Start)
+		require.Equal(t, []string{deviceService, mockService}, plan.Deferred)
+		require.Contains(t, plan.String(), "Start once plugs are connected: "+deviceService+", "+mockService)
+
+		// the plug is connected in the connect-plug hook
+		plan, err = p.ProcessConnectPlug(serialPort)
+		require.NoError(t, err)
+		require.Equal(t, []string{deviceService, mockService}, plan.Start)
+		require.Empty(t, plan.Deferred)
+		enabled, active, _ := fake.Service(deviceService)
+		require.True(t, enabled, deviceService+" enabled")
+		require.True(t, active, deviceService+" active")
+
+		// connected plugs don't defer
+		fake.Connect(serialPort)
+		plan, err = p.PlanAutostart(deviceModbus, mockApp, mockApp2)
+		require.NoError(t, err)
+		require.Empty(t, plan.Deferred)
+		require.Equal(t, []string{deviceService, mockService, mockService2}, plan.Unchanged)
+	})
+
+	t.Run("connect without autostart", func(t *testing.T) {
+		fake := installFake(t)
+		fake.AddService(deviceService, false, false)
+		fake.AddService(mockService, false, false)
+
+		plan, err := newProcessor(t).ProcessConnectPlug(serialPort)
+		require.NoError(t, err)
+		require.Empty(t, plan.Start)
+		enabled, _, _ := fake.Service(deviceService)
+		require.False(t, enabled, deviceService+" enabled")
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		fake := installFake(t)
+		fake.AddService(deviceService, true, true)
+		fake.AddService(mockService, true, true)
+
+		plan, err := newProcessor(t).ProcessDisconnectPlug(serialPort)
+		require.NoError(t, err)
+		require.Equal(t, []string{deviceService}, plan.Stop)
+		enabled, active, _ := fake.Service(deviceService)
+		require.False(t, enabled, deviceService+" enabled")
+		require.False(t, active, deviceService+" active")
+		// dependents keep running without cascade
+		_, active, _ = fake.Service(mockService)
+		require.True(t, active, mockService+" active")
+
+		p, err := options.NewProcessor(
+			options.WithRequiredPlugs(deviceModbus, serialPort),
+			options.WithAutostartDependency(mockApp, deviceModbus),
+			options.WithAutostartCascade(),
+		)
+		require.NoError(t, err)
+		plan, err = p.ProcessDisconnectPlug(serialPort)
+		require.NoError(t, err)
+		require.Equal(t, []string{mockService}, plan.Stop)
+		require.Equal(t, []string{deviceService}, plan.Unchanged)
+	})
+
+	t.Run("unknown plug", func(t *testing.T) {
+		installFake(t)
+
+		plan, err := newProcessor(t).ProcessConnectPlug("other")
+		require.NoError(t, err)
+		require.Equal(t, &options.AutostartPlan{}, plan)
+	})
+
+	t.Run("invalid plug", func(t *testing.T) {
+		_, err := options.NewProcessor(options.WithRequiredPlugs(deviceModbus, "serial port"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,377 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// Casing defines the letter case of environment variable names
+type Casing int
+
+const (
+	// UpperCase converts the names to upper case, e.g. SERVICE_PORT.
+	// This is the case expected by EdgeX services.
+	UpperCase Casing = iota
+	// LowerCase converts the names to lower case, e.g. service_port
+	LowerCase
+	// PreserveCase keeps the case of the config option keys
+	PreserveCase
+)
+
+// Processor processes snap options into environment variables
+// and the autostart of services.
+// It is configured once with functional options and is safe to reuse,
+// including by concurrent calls, since its settings cannot change.
+// The options copy the maps, slices, and registries passed to them.
+type Processor struct {
+	// Snapd uses dots for hierarchy and hyphens as segment separators
+	// These separators map to another character for environment variable names
+	segmentSeparator   string
+	hierarchySeparator string
+	hierarchy          bool
+	casing             Casing
+	envFileTemplate    *template.Template
+
+	schema               Schema
+	keyRegistries        map[string]keyRegistration
+	defaultArrayStrategy ArrayStrategy
+	arrayStrategies      map[string]ArrayStrategy
+	groups               configGroupList
+	profileApps          []string
+	secretPatterns       []string
+	unsetSecrets         bool
+	restartOnChange      bool
+	autostartDeps        autostartDependencies
+	autostartCascade     bool
+	requiredPlugs        map[string][]string
+}
+
+// ProcessorOption configures a Processor
+type ProcessorOption func(*Processor) error
+
+// NewProcessor returns a Processor configured with the given options.
+// Without options, it behaves like ProcessConfig with the default settings.
+func NewProcessor(opts ...ProcessorOption) (*Processor, error) {
+	p := defaultProcessor()
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.autostartDeps.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// WithSegmentSeparator sets the separator used to replace hyphens in config.<x-y>.
+// Default is _
+func WithSegmentSeparator(sep string) ProcessorOption {
+	return func(p *Processor) error {
+		p.segmentSeparator = sep
+		return nil
+	}
+}
+
+// WithHierarchySeparator sets the separator used to replace dots in config.<x.y>.
+// It is used only if the config hierarchy is enabled.
+// Default is _
+func WithHierarchySeparator(sep string) ProcessorOption {
+	return func(p *Processor) error {
+		p.hierarchySeparator = sep
+		return nil
+	}
+}
+
+// WithConfigHierarchy allows config options such as config.<x.y>
+// with dots as the config key
+func WithConfigHierarchy() ProcessorOption {
+	return func(p *Processor) error {
+		p.hierarchy = true
+		return nil
+	}
+}
+
+// WithCasing sets the letter case of environment variable names.
+// Default is UpperCase
+func WithCasing(casing Casing) ProcessorOption {
+	return func(p *Processor) error {
+		switch casing {
+		case UpperCase, LowerCase, PreserveCase:
+			p.casing = casing
+			return nil
+		default:
+			return fmt.Errorf("unsupported casing: %d", casing)
+		}
+	}
+}
+
+// DefaultEnvFileTemplate is the env file path of most EdgeX snaps.
+// Without WithEnvFileTemplate, this path is used for all snaps
+// except edgex-app-service-configurable, which has one env file
+// at {{.SnapData}}/config/overrides.env.
+const DefaultEnvFileTemplate = "{{.SnapData}}/config/{{.App}}/overrides.env"
+
+// appServiceConfigurableEnvFileTemplate is the env file path of
+// edgex-app-service-configurable, the one outlier snap that doesn't
+// include the app name in its configuration path
+const appServiceConfigurableEnvFileTemplate = "{{.SnapData}}/config/overrides.env"
+
+// WithEnvFileTemplate sets the path of the env file of each app,
+// as a text/template executed with EnvFileData,
+// e.g. "{{.SnapData}}/config/{{.App}}/res/overrides.env".
+// The path must be absolute and different for each app.
+func WithEnvFileTemplate(text string) ProcessorOption {
+	return func(p *Processor) error {
+		t, err := parseEnvFileTemplate(text)
+		if err != nil {
+			return fmt.Errorf("invalid env file template: %s", err)
+		}
+		p.envFileTemplate = t
+		return nil
+	}
+}
+
+// WithSchema sets the schema used to validate the config options
+// before processing them.
+// Unsupported types and invalid patterns are rejected.
+func WithSchema(schema Schema) ProcessorOption {
+	return func(p *Processor) error {
+		compiled, err := schema.compile()
+		if err != nil {
+			return fmt.Errorf("invalid schema: %s", err)
+		}
+		p.schema = compiled
+		return nil
+	}
+}
+
+// WithKeyRegistry sets the registry of configuration keys known to the app.
+// The config options of the app are checked against the registry.
+// Global and group config options are unknown only if none of the registries
+// of the apps in their scope know them.
+// Paths added to the registry afterwards are not known to the Processor.
+func WithKeyRegistry(app string, registry *KeyRegistry, policy UnknownKeyPolicy) ProcessorOption {
+	return func(p *Processor) error {
+		if registry == nil {
+			return fmt.Errorf("nil key registry for app: %s", app)
+		}
+		p.keyRegistries[app] = keyRegistration{registry.clone(), policy}
+		return nil
+	}
+}
+
+// WithDefaultArrayStrategy sets the strategy for arrays which have no strategy of their own.
+// Default is ArrayCommaSeparated
+func WithDefaultArrayStrategy(strategy ArrayStrategy) ProcessorOption {
+	return func(p *Processor) error {
+		p.defaultArrayStrategy = strategy
+		return nil
+	}
+}
+
+// WithArrayStrategy sets the strategy for the array under the given key.
+// The key is relative to the config scope, e.g. "clients" sets the strategy
+// for both config.clients and apps.<app>.config.clients.
+// Nested keys are separated by dots, e.g. "service.cors-allowed-origins".
+func WithArrayStrategy(key string, strategy ArrayStrategy) ProcessorOption {
+	return func(p *Processor) error {
+		p.arrayStrategies[key] = strategy
+		return nil
+	}
+}
+
+// WithConfigGroup declares a group of apps which share the config options
+// set under groups.<name>.config, e.g. for all device services of a snap.
+//
+// The config options of an app are applied in the following order,
+// each overriding the previous ones:
+//  1. config.<my.env.var>
+//  2. groups.<group>.config.<my.env.var>, for each group of the app in
+//     the order of declaration
+//  3. apps.<app>.config.<my.env.var>
+//
+// Declaring an existing group replaces its apps, without changing its order.
+func WithConfigGroup(name string, apps ...string) ProcessorOption {
+	return func(p *Processor) error {
+		if len(apps) == 0 {
+			return fmt.Errorf("empty apps list for group: %s", name)
+		}
+		p.groups = p.groups.with(name, apps)
+		return nil
+	}
+}
+
+// WithRestartOnChange makes Process restart the active services
+// whose environment variables have changed
+func WithRestartOnChange() ProcessorOption {
+	return func(p *Processor) error {
+		p.restartOnChange = true
+		return nil
+	}
+}
+
+// WithAutostartDependency declares the prerequisites of the app, which must
+// be running for the app to work, e.g. the message bus and secret store for core-data.
+// Declaring the prerequisites of an app more than once adds to them.
+// Dependency cycles are rejected by NewProcessor.
+func WithAutostartDependency(app string, prerequisites ...string) ProcessorOption {
+	return func(p *Processor) error {
+		for _, prerequisite := range prerequisites {
+			if !contains(p.autostartDeps[app], prerequisite) {
+				p.autostartDeps[app] = append(p.autostartDeps[app], prerequisite)
+			}
+		}
+		return nil
+	}
+}
+
+// WithAutostartCascade makes ProcessAutostart stop and disable the apps
+// which depend on a disabled app, instead of logging a warning
+func WithAutostartCascade() ProcessorOption {
+	return func(p *Processor) error {
+		p.autostartCascade = true
+		return nil
+	}
+}
+
+// defaultProcessor returns a Processor with the default settings
+func defaultProcessor() *Processor {
+	return &Processor{
+		segmentSeparator:     "_",
+		hierarchySeparator:   "_",
+		casing:               UpperCase,
+		keyRegistries:        make(map[string]keyRegistration),
+		defaultArrayStrategy: ArrayCommaSeparated,
+		arrayStrategies:      make(map[string]ArrayStrategy),
+		autostartDeps:        make(autostartDependencies),
+		requiredPlugs:        make(map[string][]string),
+	}
+}
+
+// globalProcessor returns a Processor with the settings of the package-level setters
+func globalProcessor() *Processor {
+	p := defaultProcessor()
+	p.segmentSeparator = envSegmentSeparator
+	p.hierarchySeparator = envHierarchySeparator
+	p.hierarchy = configHierarchy
+	return p
+}
+
+// Process processes the config options of the apps and writes their env files.
+// See ProcessConfig for details.
+func (p *Processor) Process(apps ...string) error {
+	changed, err := p.ProcessChanges(apps...)
+	if err != nil {
+		return err
+	}
+
+	if p.restartOnChange && len(changed) != 0 {
+		return restartActiveApps(changed)
+	}
+	return nil
+}
+
+// ProcessChanges is similar to Process, but returns the sorted names
+// of the apps whose environment variables have changed.
+// Env files are only written when their content has changed.
+// Changed profiles are seeded before writing the env files.
+// Consumed secret options are unset after writing the env files, if enabled.
+// It never restarts services.
+func (p *Processor) ProcessChanges(apps ...string) (changed []string, err error) {
+	plan, err := p.Plan(apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := seedProfiles(plan); err != nil {
+		return nil, err
+	}
+
+	changed, err = writeEnvFiles(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.unsetSecrets {
+		if err := unsetSecretOptions(plan.secretOptions); err != nil {
+			return nil, err
+		}
+	}
+	return changed, nil
+}
+
+// Plan is a dry run of Process.
+// It processes the config options and returns the planned changes to the env files
+// without writing them.
+func (p *Processor) Plan(apps ...string) (*ConfigPlan, error) {
+	// uncomment to enable snap debugging
+	// snapctl.Set("debug", "true")
+
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("empty apps list")
+	}
+
+	options, err := getSnapOptions(len(p.groups) != 0, len(p.profileApps) != 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// register the secrets before any value gets logged
+	secretOptions := p.registerSecrets(options, apps)
+
+	if p.schema != nil {
+		if err := p.schema.validate(options, apps, p.groups, p.isSecret); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.checkRegisteredKeys(options, apps); err != nil {
+		return nil, err
+	}
+
+	cp := newConfigProcessor(p, apps)
+
+	// process global options
+	if err := cp.processGlobalConfigOptions(options, apps); err != nil {
+		return nil, err
+	}
+
+	// process group options
+	if err := cp.processGroupConfigOptions(options, apps); err != nil {
+		return nil, err
+	}
+
+	// process app-specific options
+	if err := cp.processAppConfigOptions(options, apps); err != nil {
+		return nil, err
+	}
+
+	// process profile options
+	if err := cp.processProfileOptions(options, apps); err != nil {
+		return nil, err
+	}
+
+	plan, err := cp.plan()
+	if err != nil {
+		return nil, err
+	}
+	plan.secretOptions = secretOptions
+	return plan, nil
+}
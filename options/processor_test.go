@@ -0,0 +1,233 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/env"
+	"github.com/canonical/edgex-snap-hooks/v3/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessor(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.service-port", 8080)
+
+		p, err := options.NewProcessor()
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+		require.NoError(t, fileContains(t, envFilePath(testService), `SERVICE_PORT="8080"`),
+			"File content:\n%s", readFile(t, envFilePath(testService)))
+	})
+
+	t.Run("independent settings", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config", map[string]interface{}{
+			"service": map[string]interface{}{"server-bind-addr": "0.0.0.0"},
+		})
+
+		hierarchical, err := options.NewProcessor(
+			options.WithConfigHierarchy(),
+			options.WithHierarchySeparator("__"),
+			options.WithSegmentSeparator(""),
+		)
+		require.NoError(t, err)
+		plan, err := hierarchical.Plan(testService)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"SERVICE__SERVERBINDADDR": "0.0.0.0"}, plan.Apps[0].Planned)
+
+		// the default processor rejects dots
+		flat, err := options.NewProcessor()
+		require.NoError(t, err)
+		_, err = flat.Plan(testService)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "must not contain dots")
+	})
+
+	t.Run("casing", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.service-port", 8080)
+
+		for casing, expected := range map[options.Casing]string{
+			options.UpperCase:    "SERVICE_PORT",
+			options.LowerCase:    "service_port",
+			options.PreserveCase: "service_port",
+		} {
+			p, err := options.NewProcessor(options.WithCasing(casing))
+			require.NoError(t, err)
+			plan, err := p.Plan(testService)
+			require.NoError(t, err)
+			require.Equal(t, map[string]string{expected: "8080"}, plan.Apps[0].Planned)
+		}
+
+		_, err := options.NewProcessor(options.WithCasing(options.Casing(42)))
+		require.Error(t, err)
+	})
+
+	t.Run("schema and groups", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("groups.devices.config.debug", "yes")
+
+		p, err := options.NewProcessor(
+			options.WithConfigGroup("devices", testService),
+			options.WithSchema(options.Schema{"debug": {Type: options.TypeBool}}),
+		)
+		require.NoError(t, err)
+
+		var validationErr *options.ValidationError
+		require.ErrorAs(t, p.Process(testService), &validationErr)
+		require.Equal(t, []string{`groups.devices.config.debug: expected bool, got "yes"`},
+			validationErr.Problems)
+
+		// the package-level settings have no groups
+		require.NoError(t, options.ProcessConfig(testService))
+		require.False(t, fileExists(t, envFilePath(testService)), "Env file should not exist.")
+	})
+
+	t.Run("copied settings", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("groups.devices.config.debug", "yes")
+
+		schema := options.Schema{"debug": {Type: options.TypeString, Enum: []string{"yes"}}}
+		apps := []string{testService}
+		p, err := options.NewProcessor(
+			options.WithSchema(schema),
+			options.WithConfigGroup("devices", apps...),
+		)
+		require.NoError(t, err)
+
+		registry := options.NewKeyRegistry("Debug")
+		withRegistry, err := options.NewProcessor(
+			options.WithConfigGroup("devices", testService),
+			options.WithKeyRegistry(testService, registry, options.RejectUnknownKeys),
+		)
+		require.NoError(t, err)
+
+		// changing the settings after creating the processors has no effect
+		schema["debug"].Enum[0] = "no"
+		schema["other"] = options.OptionSchema{}
+		apps[0] = testService2
+		registry.Add("Other")
+
+		plan, err := p.Plan(testService)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"DEBUG": "yes"}, plan.Apps[0].Planned)
+
+		fake.SetConfig("groups.devices.config.other", "yes")
+		_, err = p.Plan(testService)
+		var validationErr *options.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, []string{
+			"groups.devices.config.other: unknown option",
+		}, validationErr.Problems)
+
+		_, err = withRegistry.Plan(testService)
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, []string{
+			"groups.devices.config.other: unknown EdgeX configuration key",
+		}, validationErr.Problems)
+	})
+
+	t.Run("env file template", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.debug", true)
+
+		p, err := options.NewProcessor(
+			options.WithEnvFileTemplate("{{.SnapData}}/{{.SnapName}}/{{.App}}/res/overrides.env"))
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+
+		file := filepath.Join(env.SnapData, env.SnapName, testService, "res", "overrides.env")
+		require.NoError(t, fileContains(t, file, `DEBUG="true"`))
+		require.False(t, fileExists(t, envFilePath(testService)), "Default env file should not exist.")
+	})
+
+	t.Run("default env file template", func(t *testing.T) {
+		installFake(t)
+
+		p, err := options.NewProcessor(options.WithEnvFileTemplate(options.DefaultEnvFileTemplate))
+		require.NoError(t, err)
+		plan, err := p.Plan(testService)
+		require.NoError(t, err)
+		require.Equal(t, envFilePath(testService), plan.Apps[0].File)
+	})
+
+	t.Run("app-service-configurable", func(t *testing.T) {
+		installFake(t)
+		env.SnapName = "edgex-app-service-configurable"
+
+		p, err := options.NewProcessor()
+		require.NoError(t, err)
+		plan, err := p.Plan(testService)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(env.SnapData, "config", "overrides.env"), plan.Apps[0].File)
+	})
+
+	t.Run("SNAP_DATA not set", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.debug", true)
+		env.SnapData = ""
+
+		p, err := options.NewProcessor()
+		require.NoError(t, err)
+		_, err = p.Plan(testService)
+		require.EqualError(t, err, "error getting env file of test-service: SNAP_DATA is not set")
+
+		// the same applies to templates
+		p, err = options.NewProcessor(options.WithEnvFileTemplate(options.DefaultEnvFileTemplate))
+		require.NoError(t, err)
+		_, err = p.Plan(testService)
+		require.EqualError(t, err, "error getting env file of test-service: SNAP_DATA is not set")
+
+		// unless they don't depend on it
+		p, err = options.NewProcessor(options.WithEnvFileTemplate("{{.Snap}}/{{.App}}.env"))
+		require.NoError(t, err)
+		plan, err := p.Plan(testService)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(env.Snap, testService+".env"), plan.Apps[0].File)
+	})
+
+	t.Run("shared env file", func(t *testing.T) {
+		installFake(t)
+
+		p, err := options.NewProcessor(options.WithEnvFileTemplate("{{.SnapData}}/overrides.env"))
+		require.NoError(t, err)
+		_, err = p.Plan(testService, testService2)
+		require.EqualError(t, err, "apps test-service and test-service2 have the same env file: "+
+			filepath.Join(env.SnapData, "overrides.env"))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := options.NewProcessor(options.WithConfigGroup("empty"))
+		require.Error(t, err)
+
+		for _, text := range []string{
+			"{{.SnapData}/overrides.env",
+			"{{.Unknown}}/overrides.env",
+			"config/{{.App}}/overrides.env",
+		} {
+			_, err = options.NewProcessor(options.WithEnvFileTemplate(text))
+			require.Error(t, err, text)
+		}
+
+		_, err = options.NewProcessor(options.WithKeyRegistry(testService, nil, options.WarnUnknownKeys))
+		require.Error(t, err)
+	})
+}
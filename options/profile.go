@@ -0,0 +1,246 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/canonical/edgex-snap-hooks/v3/env"
+	"github.com/canonical/edgex-snap-hooks/v3/log"
+)
+
+// profileEnvVar is the environment variable read by EdgeX services
+// to select the configuration profile
+const profileEnvVar = "EDGEX_PROFILE"
+
+// WithProfiles enables configuration profiles for the apps.
+//
+// The profile of an app is set via apps.<app>.profile, or for all apps with
+// profiles via the global profile option, e.g.:
+//
+//	snap set edgex-snap-name apps.<app>.profile=<profile>
+//
+// The app setting takes precedence over the global one.
+// The profiles of an app are the directories under $SNAP/config/<app>/res.
+// The selected profile is written to the env file of the app as EDGEX_PROFILE,
+// overriding config options which map to the same environment variable.
+// When the profile of an app changes, the files of the profile are seeded into
+// $SNAP_DATA/config/<app>/res/<profile>, keeping the files that already exist.
+func WithProfiles(apps ...string) ProcessorOption {
+	return func(p *Processor) error {
+		if len(apps) == 0 {
+			return fmt.Errorf("empty apps list for profiles")
+		}
+		p.profileApps = append(p.profileApps, apps...)
+		return nil
+	}
+}
+
+// profileDir returns the directory of the app's profiles under the given root,
+// i.e. $SNAP or $SNAP_DATA
+func profileDir(root, app string) string {
+	return filepath.Join(root, "config", app, "res")
+}
+
+// availableProfiles returns the sorted names of the profiles of the app
+func availableProfiles(app string) ([]string, error) {
+	if env.Snap == "" {
+		return nil, fmt.Errorf("error reading profiles of %s: SNAP is not set", app)
+	}
+	entries, err := os.ReadDir(profileDir(env.Snap, app))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading profiles of %s: %s", app, err)
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles)
+	return profiles, nil
+}
+
+// profileValue returns the profile set by the snap option, if any.
+// An empty string is the same as an unset option.
+func profileValue(option string, value interface{}) (string, bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, v != "", nil
+	default:
+		return "", false, fmt.Errorf("invalid value for '%s' option: expected string, got %T", option, value)
+	}
+}
+
+// Process the "profile" and "apps.<app>.profile" configuration
+//
+//	-> setting env var EDGEX_PROFILE for each app with profiles
+func (cp *configProcessor) processProfileOptions(options *snapOptions, services []string) error {
+	if len(cp.profileApps) == 0 {
+		return nil
+	}
+
+	// make sure that apps with a set profile have profiles
+	var names []string
+	for name := range options.Apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if options.Apps[name].Profile != nil && !contains(cp.profileApps, name) {
+			return fmt.Errorf("unsupported app in app profile option: %s. Apps with profiles are: %v",
+				name,
+				cp.profileApps,
+			)
+		}
+	}
+
+	globalProfile, globalSet, err := profileValue("profile", options.Profile)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, service := range services {
+		if !contains(cp.profileApps, service) {
+			continue
+		}
+
+		option := "apps." + service + ".profile"
+		profile, set, err := profileValue(option, options.Apps[service].Profile)
+		if err != nil {
+			return err
+		}
+		if !set {
+			option, profile, set = "profile", globalProfile, globalSet
+		}
+		if !set {
+			log.Debugf("No profile for %s", service)
+			continue
+		}
+
+		available, err := availableProfiles(service)
+		if err != nil {
+			return err
+		}
+		if !contains(available, profile) {
+			problems = append(problems, fmt.Sprintf("%s: unknown profile %q for %s, available profiles are: %s",
+				option, profile, service, strings.Join(available, ", ")))
+			continue
+		}
+
+		log.Debugf("Processing profile for %s: %s", service, profile)
+		if previous, found := cp.appEnvSources[service][profileEnvVar]; found {
+			log.Debugf("%s: %s overrides %s for %s", profileEnvVar, option, previous, service)
+		}
+		cp.appEnvVars[service][profileEnvVar] = profile
+		cp.appEnvSources[service][profileEnvVar] = option
+		delete(cp.appSecrets[service], profileEnvVar)
+		cp.appProfiles[service] = profile
+	}
+
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// seedProfiles copies the files of the changed profiles into $SNAP_DATA
+func seedProfiles(plan *ConfigPlan) error {
+	for _, appPlan := range plan.Apps {
+		if appPlan.Profile == "" {
+			continue
+		}
+		if env.Snap == "" || env.SnapData == "" {
+			return fmt.Errorf("error seeding profile %s of %s: SNAP or SNAP_DATA is not set",
+				appPlan.Profile, appPlan.App)
+		}
+
+		dst := filepath.Join(profileDir(env.SnapData, appPlan.App), appPlan.Profile)
+		_, err := os.Stat(dst)
+		if appPlan.Current[profileEnvVar] == appPlan.Profile && err == nil {
+			log.Debugf("Profile %s of %s is unchanged", appPlan.Profile, appPlan.App)
+			continue
+		}
+
+		src := filepath.Join(profileDir(env.Snap, appPlan.App), appPlan.Profile)
+		log.Infof("Seeding profile %s of %s into %s", appPlan.Profile, appPlan.App, dst)
+		if err := seedDir(src, dst); err != nil {
+			return fmt.Errorf("error seeding profile %s of %s: %s", appPlan.Profile, appPlan.App, err)
+		}
+	}
+	return nil
+}
+
+// seedDir copies the directory tree, without overwriting existing files
+func seedDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if _, err := os.Lstat(target); err == nil {
+			log.Debugf("Keeping existing file %s", target)
+			return nil
+		}
+		return seedFile(path, target)
+	})
+}
+
+func seedFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/env"
+	"github.com/canonical/edgex-snap-hooks/v3/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	t.Run("app profile", func(t *testing.T) {
+		fake := installFake(t)
+		installProfiles(t, testService, "rules-engine", "http-export")
+		fake.SetConfig("apps."+testService+".profile", "rules-engine")
+
+		p, err := options.NewProcessor(options.WithProfiles(testService))
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+
+		require.NoError(t, fileContains(t, envFilePath(testService), `EDGEX_PROFILE="rules-engine"`),
+			"File content:\n%s", readFile(t, envFilePath(testService)))
+		require.NoError(t, fileContains(t, envFilePath(testService), "# source: apps."+testService+".profile"))
+		require.Equal(t, "rules-engine config\n",
+			readFile(t, filepath.Join(env.SnapData, "config", testService, "res", "rules-engine", "configuration.yaml")))
+	})
+
+	t.Run("global profile", func(t *testing.T) {
+		fake := installFake(t)
+		installProfiles(t, testService, "rules-engine", "http-export")
+		installProfiles(t, testService2, "http-export")
+		fake.SetConfig("profile", "http-export")
+
+		p, err := options.NewProcessor(options.WithProfiles(testService, testService2))
+		require.NoError(t, err)
+		plan, err := p.Plan(testService, testService2)
+		require.NoError(t, err)
+		for _, app := range plan.Apps {
+			require.Equal(t, "http-export", app.Profile)
+			require.Equal(t, "http-export", app.Planned["EDGEX_PROFILE"])
+			source, found := plan.Source(app.App, "EDGEX_PROFILE")
+			require.True(t, found)
+			require.Equal(t, "profile", source)
+		}
+
+		// the app profile takes precedence
+		fake.SetConfig("apps."+testService+".profile", "rules-engine")
+		plan, err = p.Plan(testService, testService2)
+		require.NoError(t, err)
+		require.Equal(t, "rules-engine", plan.Apps[0].Planned["EDGEX_PROFILE"])
+		require.Equal(t, "http-export", plan.Apps[1].Planned["EDGEX_PROFILE"])
+	})
+
+	t.Run("overrides config option", func(t *testing.T) {
+		fake := installFake(t)
+		installProfiles(t, testService, "rules-engine")
+		fake.SetConfig("config.edgex-profile", "other")
+		fake.SetConfig("apps."+testService+".profile", "rules-engine")
+
+		p, err := options.NewProcessor(options.WithProfiles(testService))
+		require.NoError(t, err)
+		plan, err := p.Plan(testService)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"EDGEX_PROFILE": "rules-engine"}, plan.Apps[0].Planned)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		fake := installFake(t)
+		installProfiles(t, testService, "rules-engine", "http-export")
+		fake.SetConfig("apps."+testService+".profile", "rules")
+
+		p, err := options.NewProcessor(options.WithProfiles(testService))
+		require.NoError(t, err)
+		err = p.Process(testService)
+		var validationErr *options.ValidationError
+		require.True(t, errors.As(err, &validationErr), "unexpected error: %v", err)
+		require.Equal(t, []string{
+			`apps.test-service.profile: unknown profile "rules" for test-service, available profiles are: http-export, rules-engine`,
+		}, validationErr.Problems)
+		require.False(t, fileExists(t, envFilePath(testService)))
+	})
+
+	t.Run("app without profiles", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("apps."+testService2+".profile", "rules-engine")
+
+		p, err := options.NewProcessor(options.WithProfiles(testService))
+		require.NoError(t, err)
+		_, err = p.Plan(testService, testService2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported app in app profile option: "+testService2)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("profile", 1)
+
+		p, err := options.NewProcessor(options.WithProfiles(testService))
+		require.NoError(t, err)
+		_, err = p.Plan(testService)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "expected string")
+	})
+
+	t.Run("SNAP not set", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("apps."+testService+".profile", "rules-engine")
+		env.Snap = ""
+
+		p, err := options.NewProcessor(options.WithProfiles(testService))
+		require.NoError(t, err)
+		_, err = p.Plan(testService)
+		require.EqualError(t, err, "error reading profiles of test-service: SNAP is not set")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("profile", "rules-engine")
+
+		plan, err := options.PlanConfig(testService)
+		require.NoError(t, err)
+		require.Empty(t, plan.Apps[0].Planned)
+	})
+
+	t.Run("seed on change", func(t *testing.T) {
+		fake := installFake(t)
+		installProfiles(t, testService, "rules-engine", "http-export")
+		fake.SetConfig("apps."+testService+".profile", "rules-engine")
+
+		p, err := options.NewProcessor(options.WithProfiles(testService))
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+
+		// local changes are kept
+		seeded := filepath.Join(env.SnapData, "config", testService, "res", "rules-engine", "configuration.yaml")
+		require.NoError(t, os.WriteFile(seeded, []byte("modified\n"), 0644))
+
+		fake.SetConfig("apps."+testService+".profile", "http-export")
+		changed, err := p.ProcessChanges(testService)
+		require.NoError(t, err)
+		require.Equal(t, []string{testService}, changed)
+		require.Equal(t, "http-export config\n",
+			readFile(t, filepath.Join(env.SnapData, "config", testService, "res", "http-export", "configuration.yaml")))
+
+		fake.SetConfig("apps."+testService+".profile", "rules-engine")
+		_, err = p.ProcessChanges(testService)
+		require.NoError(t, err)
+		require.Equal(t, "modified\n", readFile(t, seeded))
+	})
+}
+
+// installProfiles creates the profiles of the app under $SNAP
+func installProfiles(t *testing.T, app string, profiles ...string) {
+	for _, profile := range profiles {
+		dir := filepath.Join(env.Snap, "config", app, "res", profile)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "configuration.yaml"), []byte(profile+" config\n"), 0644))
+	}
+}
@@ -0,0 +1,377 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/canonical/edgex-snap-hooks/v3/log"
+	"gopkg.in/yaml.v3"
+)
+
+// bootstrapEnvVars are the environment variables read by EdgeX services
+// in addition to those overriding configuration keys
+var bootstrapEnvVars = []string{
+	"EDGEX_COMMON_CONFIG",
+	"EDGEX_CONFIG_DIR",
+	"EDGEX_CONFIG_FILE",
+	"EDGEX_CONFIG_PROVIDER",
+	"EDGEX_PROFILE",
+	"EDGEX_REMOTE_SERVICE_HOSTS",
+	"EDGEX_SECURITY_SECRET_STORE",
+	"EDGEX_STARTUP_DURATION",
+	"EDGEX_STARTUP_INTERVAL",
+	"EDGEX_USE_REGISTRY",
+}
+
+// KeyRegistry holds the configuration keys known to an EdgeX service.
+//
+// EdgeX services override a configuration key with the environment variable
+// which has the key path in upper case, with dots and hyphens replaced by
+// underscores, e.g. Clients.core-metadata.Host by CLIENTS_CORE_METADATA_HOST.
+// The registry uses the same normalization to match snap options,
+// regardless of the configured separators.
+type KeyRegistry struct {
+	// paths maps normalized names to configuration paths
+	paths map[string]string
+}
+
+// NewKeyRegistry returns a registry with the given configuration paths,
+// e.g. Service.Port, as well as the environment variables used to bootstrap
+// EdgeX services, e.g. EDGEX_SECURITY_SECRET_STORE
+func NewKeyRegistry(paths ...string) *KeyRegistry {
+	r := &KeyRegistry{
+		paths: make(map[string]string),
+	}
+	r.Add(bootstrapEnvVars...)
+	r.Add(paths...)
+	return r
+}
+
+// LoadKeyRegistry returns a registry with all configuration paths of the given
+// EdgeX configuration files. YAML (.yaml, .yml) and TOML (.toml) files are supported.
+func LoadKeyRegistry(files ...string) (*KeyRegistry, error) {
+	r := NewKeyRegistry()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var paths []string
+		switch ext := filepath.Ext(file); ext {
+		case ".yaml", ".yml":
+			paths, err = yamlPaths(data)
+		case ".toml":
+			paths, err = tomlPaths(data)
+		default:
+			return nil, fmt.Errorf("unsupported configuration file type: %s", file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %s", file, err)
+		}
+		r.Add(paths...)
+	}
+	return r, nil
+}
+
+// Add adds configuration paths to the registry
+func (r *KeyRegistry) Add(paths ...string) {
+	for _, path := range paths {
+		r.paths[normalizeKey(path)] = path
+	}
+}
+
+// clone returns a copy of the registry, which doesn't change with the original
+func (r *KeyRegistry) clone() *KeyRegistry {
+	c := &KeyRegistry{
+		paths: make(map[string]string, len(r.paths)),
+	}
+	for name, path := range r.paths {
+		c.paths[name] = path
+	}
+	return c
+}
+
+// Paths returns all configuration paths of the registry, sorted
+func (r *KeyRegistry) Paths() []string {
+	var paths []string
+	for _, path := range r.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// lookup returns the configuration path that matches the snap option key
+func (r *KeyRegistry) lookup(key string) (string, bool) {
+	path, found := r.paths[normalizeKey(key)]
+	return path, found
+}
+
+// suggest returns the configuration paths similar to the snap option key,
+// most similar first
+func (r *KeyRegistry) suggest(key string) []string {
+	const maxSuggestions = 3
+
+	name := normalizeKey(key)
+	maxDistance := minInt(len(name)/4, 2)
+
+	type candidate struct {
+		path     string
+		distance int
+	}
+	var candidates []candidate
+	for n, path := range r.paths {
+		if d := levenshtein(name, n); d <= maxDistance {
+			candidates = append(candidates, candidate{path, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].path < candidates[j].path
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].path)
+	}
+	return suggestions
+}
+
+// normalizeKey converts a configuration path or snap option key
+// to the environment variable name expected by EdgeX
+func normalizeKey(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// UnknownKeyPolicy defines the handling of config options which don't map
+// to a key in the registry
+type UnknownKeyPolicy int
+
+const (
+	// WarnUnknownKeys logs a warning and processes the option
+	WarnUnknownKeys UnknownKeyPolicy = iota
+	// RejectUnknownKeys fails the processing with a *ValidationError
+	RejectUnknownKeys
+)
+
+type keyRegistration struct {
+	registry *KeyRegistry
+	policy   UnknownKeyPolicy
+}
+
+// checkRegisteredKeys checks the config options against the registries of the apps.
+// It logs warnings and returns a *ValidationError listing the rejected options.
+func (p *Processor) checkRegisteredKeys(options *snapOptions, apps []string) error {
+	var problems []string
+
+	report := func(option string, r keyRegistration, suggestions []string) {
+		msg := fmt.Sprintf("%s: unknown EdgeX configuration key", option)
+		if len(suggestions) != 0 {
+			for i := range suggestions {
+				suggestions[i] = p.suggestedOption(option, suggestions[i])
+			}
+			msg += fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, " or "))
+		}
+		if r.policy == RejectUnknownKeys {
+			problems = append(problems, msg)
+		} else {
+			log.Warn(msg)
+		}
+	}
+
+	if options.Config != nil {
+		// the registrations of the processed apps
+		var registrations []keyRegistration
+		for _, app := range apps {
+			if r, found := p.keyRegistries[app]; found {
+				registrations = append(registrations, r)
+			}
+		}
+
+		keys, err := p.getConfigMap(*options.Config)
+		if err != nil {
+			return err
+		}
+		for key := range keys.values {
+			checkKey(key, registrations, func(r keyRegistration, suggestions []string) {
+				report("config."+key, r, suggestions)
+			})
+		}
+	}
+
+	for _, group := range p.groups {
+		if options.Groups[group.name].Config == nil {
+			continue
+		}
+		var registrations []keyRegistration
+		for _, app := range p.groups.members(group.name, apps) {
+			if r, found := p.keyRegistries[app]; found {
+				registrations = append(registrations, r)
+			}
+		}
+
+		keys, err := p.getConfigMap(*options.Groups[group.name].Config)
+		if err != nil {
+			return err
+		}
+		for key := range keys.values {
+			checkKey(key, registrations, func(r keyRegistration, suggestions []string) {
+				report("groups."+group.name+".config."+key, r, suggestions)
+			})
+		}
+	}
+
+	for _, app := range apps {
+		r, found := p.keyRegistries[app]
+		if !found || options.Apps[app].Config == nil {
+			continue
+		}
+		keys, err := p.getConfigMap(*options.Apps[app].Config)
+		if err != nil {
+			return err
+		}
+		for key := range keys.values {
+			checkKey(key, []keyRegistration{r}, func(r keyRegistration, suggestions []string) {
+				report("apps."+app+".config."+key, r, suggestions)
+			})
+		}
+	}
+
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checkKey calls unknown if the key is unknown to all registrations,
+// with the strictest registration and suggestions from all registrations
+func checkKey(key string, registrations []keyRegistration, unknown func(keyRegistration, []string)) {
+	if len(registrations) == 0 {
+		return
+	}
+
+	strictest := registrations[0]
+	suggestions := make(map[string]bool)
+	for _, r := range registrations {
+		if _, found := r.registry.lookup(key); found {
+			return
+		}
+		if r.policy > strictest.policy {
+			strictest = r
+		}
+		for _, s := range r.registry.suggest(key) {
+			suggestions[s] = true
+		}
+	}
+
+	var list []string
+	for s := range suggestions {
+		list = append(list, s)
+	}
+	sort.Strings(list)
+	unknown(strictest, list)
+}
+
+// suggestedOption converts a configuration path to a snap option in the same
+// scope as the given option, e.g. config.service.port for Service.Port
+func (p *Processor) suggestedOption(option, path string) string {
+	scope := option[:strings.LastIndex(option, "config.")+len("config.")]
+	sep := "-"
+	if p.hierarchy {
+		sep = "."
+	}
+	return scope + strings.ToLower(strings.ReplaceAll(path, ".", sep))
+}
+
+// yamlPaths returns the paths of all leaf keys in the YAML document
+func yamlPaths(data []byte) ([]string, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return leafPaths(doc), nil
+}
+
+// tomlPaths returns the paths of all leaf keys in the TOML document.
+// The keys of inline tables are leaf keys as well, while arrays,
+// including arrays of tables, are values.
+func tomlPaths(data []byte) ([]string, error) {
+	var doc map[string]interface{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return leafPaths(doc), nil
+}
+
+// leafPaths returns the dotted paths of all leaf keys in the document
+func leafPaths(doc map[string]interface{}) []string {
+	var paths []string
+	var walk func(prefix string, value interface{})
+	walk = func(prefix string, value interface{}) {
+		if object, ok := value.(map[string]interface{}); ok && len(object) != 0 {
+			for k, v := range object {
+				walk(prefix+"."+k, v)
+			}
+			return
+		}
+		if prefix != "" {
+			paths = append(paths, strings.TrimPrefix(prefix, "."))
+		}
+	}
+	walk("", doc)
+	return paths
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(first int, rest ...int) int {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
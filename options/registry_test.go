@@ -0,0 +1,207 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/options"
+	"github.com/stretchr/testify/require"
+)
+
+const registryYAML = `
+Writable:
+  LogLevel: INFO
+  InsecureSecrets:
+    DB:
+      SecretName: redisdb
+Service:
+  Host: localhost
+  Port: 59881
+Clients:
+  core-metadata:
+    Host: localhost
+`
+
+const registryTOML = `
+# comment
+MaxEventSize = 25000
+
+[Writable]
+LogLevel = "INFO"
+
+[Clients."core-command"]
+Host = "localhost"
+Protocols = [
+  "http",
+]
+
+[Clients.core-data]
+Hosts = [
+  { Name = "a", Port = 1 },
+  { Name = "b", Port = 2 },
+]
+Description = """
+x = 1
+"""
+Inline = { user = "u", pass = "p" }
+`
+
+func TestLoadKeyRegistry(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "configuration.yaml")
+	tomlFile := filepath.Join(dir, "configuration.toml")
+	require.NoError(t, os.WriteFile(yamlFile, []byte(registryYAML), 0644))
+	require.NoError(t, os.WriteFile(tomlFile, []byte(registryTOML), 0644))
+
+	t.Run("yaml", func(t *testing.T) {
+		r, err := options.LoadKeyRegistry(yamlFile)
+		require.NoError(t, err)
+		require.Subset(t, r.Paths(), []string{
+			"Clients.core-metadata.Host",
+			"EDGEX_SECURITY_SECRET_STORE",
+			"Service.Host",
+			"Service.Port",
+			"Writable.InsecureSecrets.DB.SecretName",
+			"Writable.LogLevel",
+		})
+		require.NotContains(t, r.Paths(), "Service")
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		r, err := options.LoadKeyRegistry(tomlFile)
+		require.NoError(t, err)
+		require.Subset(t, r.Paths(), []string{
+			"Clients.core-command.Host",
+			"Clients.core-command.Protocols",
+			"MaxEventSize",
+			"Writable.LogLevel",
+		})
+	})
+
+	t.Run("toml multi-line values and inline tables", func(t *testing.T) {
+		r, err := options.LoadKeyRegistry(tomlFile)
+		require.NoError(t, err)
+		require.Subset(t, r.Paths(), []string{
+			"Clients.core-data.Description",
+			"Clients.core-data.Hosts",
+			"Clients.core-data.Inline.pass",
+			"Clients.core-data.Inline.user",
+		})
+		for _, path := range r.Paths() {
+			require.NotContains(t, path, "{", "unexpected path: %s", path)
+		}
+		require.NotContains(t, r.Paths(), "Clients.core-data.x")
+		require.NotContains(t, r.Paths(), "Clients.core-data.Inline")
+	})
+
+	t.Run("invalid toml", func(t *testing.T) {
+		file := filepath.Join(dir, "invalid.toml")
+		require.NoError(t, os.WriteFile(file, []byte("[Writable\nLogLevel = INFO\n"), 0644))
+		_, err := options.LoadKeyRegistry(file)
+		require.Error(t, err)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"empty.yaml": "",
+			"empty.toml": "# comment\n",
+		} {
+			file := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+			r, err := options.LoadKeyRegistry(file)
+			require.NoError(t, err)
+			require.Equal(t, options.NewKeyRegistry().Paths(), r.Paths(), name)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := options.LoadKeyRegistry(filepath.Join(dir, "configuration.json"))
+		require.Error(t, err)
+	})
+}
+
+func TestKeyRegistry(t *testing.T) {
+	registry := options.NewKeyRegistry(
+		"Service.Host",
+		"Service.Port",
+		"Writable.LogLevel",
+		"Clients.core-metadata.Host",
+	)
+
+	t.Run("known keys", func(t *testing.T) {
+		p, err := options.NewProcessor(options.WithKeyRegistry(testService, registry, options.RejectUnknownKeys))
+		require.NoError(t, err)
+
+		fake := installFake(t)
+		fake.SetConfig("config", map[string]interface{}{
+			"edgex-security-secret-store": "false",
+			"writable-loglevel":           "DEBUG",
+		})
+		fake.SetConfig("apps."+testService+".config", map[string]interface{}{
+			"service-port":               8080,
+			"clients-core-metadata-host": "localhost",
+		})
+
+		require.NoError(t, p.Process(testService))
+		require.NoError(t, fileContains(t, envFilePath(testService), `SERVICE_PORT="8080"`),
+			"File content:\n%s", readFile(t, envFilePath(testService)))
+	})
+
+	t.Run("reject unknown keys", func(t *testing.T) {
+		p, err := options.NewProcessor(options.WithKeyRegistry(testService, registry, options.RejectUnknownKeys))
+		require.NoError(t, err)
+
+		fake := installFake(t)
+		fake.SetConfig("config", map[string]interface{}{
+			"writable-loglevl": "DEBUG",
+		})
+		fake.SetConfig("apps."+testService+".config", map[string]interface{}{
+			"service-prot": 8080,
+			"foo":          "bar",
+		})
+
+		err = p.Process(testService)
+		require.Error(t, err)
+
+		var validationErr *options.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, []string{
+			"apps.test-service.config.foo: unknown EdgeX configuration key",
+			"apps.test-service.config.service-prot: unknown EdgeX configuration key, did you mean apps.test-service.config.service-port?",
+			"config.writable-loglevl: unknown EdgeX configuration key, did you mean config.writable-loglevel?",
+		}, validationErr.Problems)
+
+		require.False(t, fileExists(t, envFilePath(testService)), "Env file should not exist.")
+	})
+
+	t.Run("warn unknown keys", func(t *testing.T) {
+		p, err := options.NewProcessor(options.WithKeyRegistry(testService, registry, options.WarnUnknownKeys))
+		require.NoError(t, err)
+
+		fake := installFake(t)
+		fake.SetConfig("apps."+testService+".config", map[string]interface{}{
+			"foo": "bar",
+		})
+
+		require.NoError(t, p.Process(testService))
+		require.NoError(t, fileContains(t, envFilePath(testService), `FOO="bar"`),
+			"File content:\n%s", readFile(t, envFilePath(testService)))
+	})
+}
@@ -0,0 +1,348 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OptionType is the expected type of a config option value
+type OptionType string
+
+const (
+	// TypeAny accepts any string, bool, or number
+	TypeAny OptionType = ""
+	// TypeString accepts strings
+	TypeString OptionType = "string"
+	// TypeBool accepts true or false
+	TypeBool OptionType = "bool"
+	// TypeInt accepts numbers without a fractional part
+	TypeInt OptionType = "int"
+	// TypeNumber accepts any number
+	TypeNumber OptionType = "number"
+	// TypeArray accepts arrays of strings, bools, or numbers.
+	// The other constraints apply to each element.
+	TypeArray OptionType = "array"
+)
+
+// OptionSchema defines the constraints for the value of a config option.
+// The zero value accepts any string, bool, or number.
+type OptionSchema struct {
+	// Type is the expected type of the value
+	Type OptionType
+	// Enum is the list of allowed values, if not empty.
+	// The values are compared in their string form, e.g. "true" or "8080".
+	Enum []string
+	// Min is the minimum value of numbers, if not nil
+	Min *float64
+	// Max is the maximum value of numbers, if not nil
+	Max *float64
+	// Pattern is a regular expression which the value, in its string form, must match
+	Pattern string
+	// Requires lists other options, with keys relative to the config scope,
+	// which must be set together with this option.
+	// Options set under groups.<group>.config may be satisfied by global config options,
+	// and those under apps.<app>.config by global or group config options.
+	Requires []string
+	// Secret marks the option as secret, see WithSecrets
+	Secret bool
+
+	// pattern is the compiled Pattern, see Schema.compile
+	pattern *regexp.Regexp
+}
+
+// Schema declares the config options accepted by a snap.
+// The keys are relative to the config scope and may contain dots,
+// e.g. "service.port" declares config.service.port,
+// groups.<group>.config.service.port, and apps.<app>.config.service.port
+type Schema map[string]OptionSchema
+
+// ValidationError is returned when one or more options are invalid.
+// It lists the problems of all invalid options.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid config options:\n\t%s", strings.Join(e.Problems, "\n\t"))
+}
+
+// validate checks the global, group, and app-specific config options against the schema.
+// The values of the options for which isSecret returns true are left out of the problems.
+func (s Schema) validate(options *snapOptions, apps []string, groups configGroupList, isSecret func(key string) bool) error {
+	var problems []string
+
+	var globalKeys map[string]bool
+	if options.Config != nil {
+		var p []string
+		globalKeys, p = s.validateScope("config", *options.Config, isSecret)
+		problems = append(problems, p...)
+		problems = append(problems, s.validateRequires("config", globalKeys, nil)...)
+	}
+
+	groupKeys := make(map[string]map[string]bool)
+	for _, group := range groups {
+		if options.Groups[group.name].Config == nil {
+			continue
+		}
+		scope := "groups." + group.name + ".config"
+		keys, p := s.validateScope(scope, *options.Groups[group.name].Config, isSecret)
+		problems = append(problems, p...)
+		problems = append(problems, s.validateRequires(scope, keys, globalKeys)...)
+		groupKeys[group.name] = keys
+	}
+
+	for _, app := range apps {
+		if options.Apps[app].Config == nil {
+			continue
+		}
+
+		// keys set in the parent scopes of the app
+		parentKeys := make(map[string]bool)
+		for k := range globalKeys {
+			parentKeys[k] = true
+		}
+		for _, group := range groups.of(app) {
+			for k := range groupKeys[group] {
+				parentKeys[k] = true
+			}
+		}
+
+		scope := "apps." + app + ".config"
+		appKeys, p := s.validateScope(scope, *options.Apps[app].Config, isSecret)
+		problems = append(problems, p...)
+		problems = append(problems, s.validateRequires(scope, appKeys, parentKeys)...)
+	}
+
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateScope checks the options under one scope, e.g. config or apps.<app>.config.
+// It returns the keys of all set options and the problems found.
+func (s Schema) validateScope(scope string, config configOptions, isSecret func(key string) bool) (keys map[string]bool, problems []string) {
+	keys = make(map[string]bool)
+
+	var walk func(key string, value interface{})
+	walk = func(key string, value interface{}) {
+		if spec, found := s[key]; found {
+			keys[key] = true
+			if err := spec.validate(value); err != nil && isSecret(key) {
+				// the errors include the value
+				problems = append(problems, fmt.Sprintf("%s.%s: invalid secret value", scope, key))
+			} else if err != nil {
+				problems = append(problems, fmt.Sprintf("%s.%s: %s", scope, key, err))
+			}
+			return
+		}
+
+		if object, ok := value.(map[string]interface{}); ok && s.hasPrefix(key+".") {
+			for k, v := range object {
+				walk(key+"."+k, v)
+			}
+			return
+		}
+
+		problems = append(problems, fmt.Sprintf("%s.%s: unknown option", scope, key))
+	}
+
+	for k, v := range config {
+		walk(k, v)
+	}
+	return keys, problems
+}
+
+// validateRequires checks that the options required by the set options are also set.
+// The fallback keys are those set in a parent scope.
+func (s Schema) validateRequires(scope string, keys, fallback map[string]bool) (problems []string) {
+	for key := range keys {
+		for _, required := range s[key].Requires {
+			if !keys[required] && !fallback[required] {
+				problems = append(problems,
+					fmt.Sprintf("%s.%s: requires %s.%s to be set", scope, key, scope, required))
+			}
+		}
+	}
+	return problems
+}
+
+// clone returns a deep copy of the schema
+func (s Schema) clone() Schema {
+	if s == nil {
+		return nil
+	}
+	c := make(Schema, len(s))
+	for key, spec := range s {
+		spec.Enum = append([]string(nil), spec.Enum...)
+		spec.Requires = append([]string(nil), spec.Requires...)
+		if spec.Min != nil {
+			min := *spec.Min
+			spec.Min = &min
+		}
+		if spec.Max != nil {
+			max := *spec.Max
+			spec.Max = &max
+		}
+		c[key] = spec
+	}
+	return c
+}
+
+// compile returns a copy of the schema with the patterns compiled.
+// It returns an error if an option has an unsupported type or an invalid pattern.
+func (s Schema) compile() (Schema, error) {
+	c := s.clone()
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		spec := c[key]
+		switch spec.Type {
+		case TypeAny, TypeString, TypeBool, TypeInt, TypeNumber, TypeArray:
+		default:
+			return nil, fmt.Errorf("unsupported type for %s: %s", key, spec.Type)
+		}
+		if spec.Pattern != "" {
+			re, err := regexp.Compile(spec.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern for %s: %s", key, err)
+			}
+			spec.pattern = re
+		}
+		c[key] = spec
+	}
+	return c, nil
+}
+
+func (s Schema) hasSecrets() bool {
+	for _, spec := range s {
+		if spec.Secret {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Schema) hasPrefix(prefix string) bool {
+	for key := range s {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (spec OptionSchema) validate(value interface{}) error {
+	if array, ok := value.([]interface{}); ok {
+		if spec.Type != TypeArray {
+			return fmt.Errorf("expected %s, got array", spec.typeName())
+		}
+		elementSpec := spec
+		elementSpec.Type = TypeAny
+		for i, element := range array {
+			if err := elementSpec.validate(element); err != nil {
+				return fmt.Errorf("element %d: %s", i, err)
+			}
+		}
+		return nil
+	}
+
+	var str string
+	var number *big.Float
+
+	switch v := value.(type) {
+	case string:
+		str = v
+	case bool:
+		str = strconv.FormatBool(v)
+	case json.Number:
+		str = v.String()
+		f, ok := new(big.Float).SetString(str)
+		if !ok {
+			return fmt.Errorf("invalid number: %s", str)
+		}
+		number = f
+	case map[string]interface{}:
+		return fmt.Errorf("expected %s, got object", spec.typeName())
+	default:
+		return fmt.Errorf("expected %s, got %v", spec.typeName(), v)
+	}
+
+	switch spec.Type {
+	case TypeString:
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("expected string, got %s", str)
+		}
+	case TypeBool:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("expected bool, got %q", str)
+		}
+	case TypeInt:
+		if number == nil || !number.IsInt() {
+			return fmt.Errorf("expected int, got %q", str)
+		}
+	case TypeNumber:
+		if number == nil {
+			return fmt.Errorf("expected number, got %q", str)
+		}
+	case TypeArray:
+		return fmt.Errorf("expected array, got %s", str)
+	}
+
+	if len(spec.Enum) != 0 && !contains(spec.Enum, str) {
+		return fmt.Errorf("expected one of %v, got %s", spec.Enum, str)
+	}
+	if number != nil && spec.Min != nil && number.Cmp(big.NewFloat(*spec.Min)) < 0 {
+		return fmt.Errorf("expected minimum %v, got %s", *spec.Min, str)
+	}
+	if number != nil && spec.Max != nil && number.Cmp(big.NewFloat(*spec.Max)) > 0 {
+		return fmt.Errorf("expected maximum %v, got %s", *spec.Max, str)
+	}
+	if spec.pattern != nil && !spec.pattern.MatchString(str) {
+		return fmt.Errorf("expected value matching %s, got %s", spec.Pattern, str)
+	}
+
+	return nil
+}
+
+func (spec OptionSchema) typeName() string {
+	if spec.Type == TypeAny {
+		return "string, bool, or number"
+	}
+	return string(spec.Type)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
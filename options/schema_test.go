@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options_test
+
+import (
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSchema(t *testing.T) {
+	min, max := 1.0, 65535.0
+	p, err := options.NewProcessor(options.WithSchema(options.Schema{
+		"port":              {Type: options.TypeInt, Min: &min, Max: &max},
+		"service.host":      {Type: options.TypeString, Pattern: `^[a-z0-9.-]+$`},
+		"log-level":         {Enum: []string{"DEBUG", "INFO", "ERROR"}},
+		"debug":             {Type: options.TypeBool},
+		"tls-cert":          {Requires: []string{"tls-key"}},
+		"tls-key":           {},
+		"writable.interval": {Type: options.TypeNumber},
+		"hosts":             {Type: options.TypeArray, Pattern: `^[a-z]+$`},
+	}))
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config", map[string]interface{}{
+			"port":      8080,
+			"log-level": "DEBUG",
+			"tls-key":   "key.pem",
+			"hosts":     []interface{}{"a", "b"},
+		})
+		fake.SetConfig("apps."+testService+".config", map[string]interface{}{
+			"debug": true,
+			// tls-key is set globally
+			"tls-cert": "cert.pem",
+		})
+
+		require.NoError(t, p.Process(testService))
+
+		require.NoError(t, fileContains(t, envFilePath(testService), `PORT="8080"`),
+			"File content:\n%s", readFile(t, envFilePath(testService)))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config", map[string]interface{}{
+			"service-port": 8080,
+			"port":         70000,
+			"service": map[string]interface{}{
+				"host": "Local Host",
+			},
+			"log-level": "VERBOSE",
+			"tls-cert":  "cert.pem",
+			"writable":  map[string]interface{}{"interval": "1s"},
+			"hosts":     []interface{}{"a", "B"},
+		})
+		fake.SetConfig("apps."+testService+".config", map[string]interface{}{
+			"debug":   "yes",
+			"port":    1.5,
+			"service": "localhost",
+			"hosts":   "a",
+		})
+
+		err := p.Process(testService)
+		require.Error(t, err)
+
+		var validationErr *options.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, []string{
+			"apps.test-service.config.debug: expected bool, got \"yes\"",
+			"apps.test-service.config.hosts: expected array, got a",
+			"apps.test-service.config.port: expected int, got \"1.5\"",
+			"apps.test-service.config.service: unknown option",
+			"config.hosts: element 1: expected value matching ^[a-z]+$, got B",
+			"config.log-level: expected one of [DEBUG INFO ERROR], got VERBOSE",
+			"config.port: expected maximum 65535, got 70000",
+			"config.service-port: unknown option",
+			"config.service.host: expected value matching ^[a-z0-9.-]+$, got Local Host",
+			"config.tls-cert: requires config.tls-key to be set",
+			"config.writable.interval: expected number, got \"1s\"",
+		}, validationErr.Problems)
+
+		require.False(t, fileExists(t, envFilePath(testService)), "Env file should not exist.")
+	})
+}
+
+func TestInvalidConfigSchema(t *testing.T) {
+	for expected, schema := range map[string]options.Schema{
+		"invalid schema: unsupported type for port: integer": {
+			"port": {Type: "integer"},
+		},
+		"invalid schema: invalid pattern for service.host: error parsing regexp: missing closing ]: `[a-z`": {
+			"service.host": {Pattern: `[a-z`},
+		},
+	} {
+		_, err := options.NewProcessor(options.WithSchema(schema))
+		require.EqualError(t, err, expected)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/canonical/edgex-snap-hooks/v3/log"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+)
+
+// WithSecrets marks the config options matching the patterns as secret.
+// The patterns are matched against the keys relative to the config scope
+// using path.Match, e.g. "clients.mqtt.password" or "*password".
+// A key is also secret if one of its parents matches, or if it is marked
+// as Secret in the schema.
+//
+// The values of secret options are redacted from the log output,
+// and the environment variables they set are written to a separate secrets file
+// with 0600 permissions, instead of the env file.
+// The secrets file is next to the env file, e.g. overrides.secrets.env
+// for overrides.env, and needs to be sourced by the service wrapper scripts as well.
+func WithSecrets(patterns ...string) ProcessorOption {
+	return func(p *Processor) error {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid secret pattern %q: %s", pattern, err)
+			}
+		}
+		p.secretPatterns = append(p.secretPatterns, patterns...)
+		return nil
+	}
+}
+
+// WithUnsetSecrets makes ProcessChanges unset the secret options after
+// writing them to the secrets files, so that they are no longer readable
+// via snap get.
+// The secrets files keep the consumed secrets until they are set again.
+// A consumed secret is revoked by setting its option to an empty value, e.g.:
+//
+//	snap set edgex-snap-name config.clients-mqtt-password=""
+//
+// which removes it from the secrets files of the apps in the option's scope,
+// and is then unset as well.
+func WithUnsetSecrets() ProcessorOption {
+	return func(p *Processor) error {
+		p.unsetSecrets = true
+		return nil
+	}
+}
+
+// secretsFilename returns the path of the secrets file next to the env file
+func secretsFilename(envFile string) string {
+	ext := filepath.Ext(envFile)
+	return strings.TrimSuffix(envFile, ext) + ".secrets" + ext
+}
+
+// isSecret returns true if the key, relative to the config scope,
+// or one of its parents is secret
+func (p *Processor) isSecret(key string) bool {
+	parts := strings.Split(key, ".")
+	for i := range parts {
+		prefix := strings.Join(parts[:i+1], ".")
+		if p.schema[prefix].Secret {
+			return true
+		}
+		for _, pattern := range p.secretPatterns {
+			if matched, _ := path.Match(pattern, prefix); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// registerSecrets registers the values of the secret options of the processed
+// apps with the logger, before any of them gets logged.
+// It returns the secret options which are set, e.g. config.clients.mqtt.password
+func (p *Processor) registerSecrets(options *snapOptions, apps []string) (secretOptions []string) {
+	if len(p.secretPatterns) == 0 && !p.schema.hasSecrets() {
+		return nil
+	}
+
+	register := func(scope string, config *configOptions) {
+		if config == nil {
+			return
+		}
+		var walk func(key string, value interface{}, secret bool)
+		walk = func(key string, value interface{}, secret bool) {
+			if value == nil {
+				return
+			}
+			if !secret && p.isSecret(key) {
+				secret = true
+				secretOptions = append(secretOptions, scope+"."+key)
+			}
+			switch v := value.(type) {
+			case map[string]interface{}:
+				for k, e := range v {
+					walk(key+"."+k, e, secret)
+				}
+			case []interface{}:
+				for _, e := range v {
+					walk(key, e, secret)
+				}
+			case string:
+				if secret {
+					log.AddSecret(v)
+				}
+			case json.Number:
+				if secret {
+					log.AddSecret(v.String())
+				}
+			}
+		}
+		for k, v := range *config {
+			walk(k, v, false)
+		}
+	}
+
+	register("config", options.Config)
+	for _, group := range p.groups {
+		if len(p.groups.members(group.name, apps)) != 0 {
+			register("groups."+group.name+".config", options.Groups[group.name].Config)
+		}
+	}
+	for _, app := range apps {
+		register("apps."+app+".config", options.Apps[app].Config)
+	}
+	return secretOptions
+}
+
+// unsetSecretOptions unsets the consumed secret options
+func unsetSecretOptions(secretOptions []string) error {
+	if len(secretOptions) == 0 {
+		return nil
+	}
+	log.Infof("Unsetting consumed secret options: %s", strings.Join(secretOptions, ", "))
+	if err := snapctl.Unset(secretOptions...).Run(); err != nil {
+		return fmt.Errorf("error unsetting secret options: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2026 Canonical Ltd
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ *  in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0'
+ */
+
+package options_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/options"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecrets(t *testing.T) {
+	t.Run("pattern", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.clients-mqtt-password", "pa$$word")
+		fake.SetConfig("config.clients-mqtt-user", "admin")
+
+		p, err := options.NewProcessor(options.WithSecrets("*password"))
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+
+		envFile := readFile(t, envFilePath(testService))
+		require.Contains(t, envFile, `CLIENTS_MQTT_USER="admin"`)
+		require.NotContains(t, envFile, "pa$$word")
+
+		require.NoError(t, fileContains(t, secretsFilePath(testService), `CLIENTS_MQTT_PASSWORD="pa\$\$word"`),
+			"File content:\n%s", readFile(t, secretsFilePath(testService)))
+		info, err := os.Stat(secretsFilePath(testService))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.clients-mqtt-password", "s3cr3t-pw")
+		fake.SetConfig("apps."+testService+".config.clients-mqtt-password", "app-s3cr3t-pw")
+
+		p, err := options.NewProcessor(options.WithSchema(options.Schema{
+			"clients-mqtt-password": {Type: options.TypeString, Secret: true},
+		}))
+		require.NoError(t, err)
+		plan, err := p.Plan(testService, testService2)
+		require.NoError(t, err)
+
+		require.Empty(t, plan.Apps[0].Planned)
+		require.Equal(t, map[string]string{"CLIENTS_MQTT_PASSWORD": "app-s3cr3t-pw"}, plan.Apps[0].PlannedSecrets)
+		require.Equal(t, map[string]string{"CLIENTS_MQTT_PASSWORD": "s3cr3t-pw"}, plan.Apps[1].PlannedSecrets)
+		require.NotContains(t, plan.String(), "s3cr3t-pw")
+		require.Contains(t, plan.String(), "+CLIENTS_MQTT_PASSWORD=***")
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.clients-mqtt-password", "s3cr3t-pw")
+
+		p, err := options.NewProcessor(options.WithSecrets("*password"))
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+		require.True(t, fileExists(t, secretsFilePath(testService)))
+
+		fake.SetConfig("config.clients-mqtt-password", nil)
+		changed, err := p.ProcessChanges(testService)
+		require.NoError(t, err)
+		require.Equal(t, []string{testService}, changed)
+		require.False(t, fileExists(t, secretsFilePath(testService)))
+	})
+
+	t.Run("unset after consumption", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.clients-mqtt-password", "s3cr3t-pw")
+		fake.SetConfig("config.clients-mqtt-user", "admin")
+
+		p, err := options.NewProcessor(options.WithSecrets("*password"), options.WithUnsetSecrets())
+		require.NoError(t, err)
+		require.NoError(t, p.Process(testService))
+
+		_, found := fake.Config("config.clients-mqtt-password")
+		require.False(t, found)
+		_, found = fake.Config("config.clients-mqtt-user")
+		require.True(t, found)
+
+		// the consumed secret is kept
+		changed, err := p.ProcessChanges(testService)
+		require.NoError(t, err)
+		require.Empty(t, changed)
+		require.NoError(t, fileContains(t, secretsFilePath(testService), `CLIENTS_MQTT_PASSWORD="s3cr3t-pw"`))
+
+		// and revoked by an empty value, which gets unset as well
+		fake.SetConfig("config.clients-mqtt-password", "")
+		changed, err = p.ProcessChanges(testService)
+		require.NoError(t, err)
+		require.Equal(t, []string{testService}, changed)
+		require.False(t, fileExists(t, secretsFilePath(testService)))
+		_, found = fake.Config("config.clients-mqtt-password")
+		require.False(t, found)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		fake := installFake(t)
+		fake.SetConfig("config.clients-mqtt-password", "s3cr3t-pw")
+
+		p, err := options.NewProcessor(options.WithSchema(options.Schema{
+			"clients-mqtt-password": {Type: options.TypeString, Enum: []string{"a", "b"}, Secret: true},
+		}))
+		require.NoError(t, err)
+		_, err = p.Plan(testService)
+		var validationErr *options.ValidationError
+		require.True(t, errors.As(err, &validationErr), "unexpected error: %v", err)
+		require.Equal(t, []string{"config.clients-mqtt-password: invalid secret value"}, validationErr.Problems)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := options.NewProcessor(options.WithSecrets("[password"))
+		require.Error(t, err)
+	})
+}
+
+func secretsFilePath(app string) string {
+	return strings.TrimSuffix(envFilePath(app), ".env") + ".secrets.env"
+}
@@ -1,8 +1,6 @@
 package snapctl
 
 import (
-	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/canonical/edgex-snap-hooks/v3/log"
@@ -14,10 +12,5 @@ func run(subcommand string, subargs ...string) (string, error) {
 
 	log.Debugf("Executing 'snapctl %s'\n", strings.Join(args, " "))
 
-	output, err := exec.Command("snapctl", args...).CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("%w: %s", err, output)
-	}
-
-	return strings.TrimSpace(string(output)), nil
+	return executor.Execute(subcommand, subargs...)
 }
@@ -1,9 +1,42 @@
 package snapctl_test
 
-import "testing"
+import (
+	"errors"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
+	"github.com/stretchr/testify/require"
+)
 
 func TestRun(t *testing.T) {
-	t.Skip("TODO")
-	// error handling
-	// stderr
+	t.Run("custom executor", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+
+		_, err := snapctl.Get("test-key").Document().Run()
+		require.NoError(t, err)
+		require.Equal(t, []snapctltest.Call{
+			{Subcommand: "get", Args: []string{"-d", "test-key"}},
+		}, fake.Calls())
+	})
+
+	t.Run("error handling", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+		failure := errors.New("snapctl failed")
+		fake.FailOn("get", failure)
+
+		// the executor's error is returned as is
+		_, err := snapctl.Get("test-key").Run()
+		require.ErrorIs(t, err, failure)
+
+		// other subcommands are not affected
+		require.NoError(t, snapctl.Set("test-key", "value").Run())
+
+		// invalid commands fail before being executed
+		fake.ResetCalls()
+		_, err = snapctl.Get("test key").Run()
+		require.Error(t, err)
+		require.NotErrorIs(t, err, failure)
+		require.Empty(t, fake.Calls())
+	})
 }
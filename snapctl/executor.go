@@ -0,0 +1,45 @@
+package snapctl
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Executor executes snapctl subcommands.
+// All command builders of this package delegate to the executor set via SetExecutor.
+type Executor interface {
+	// Execute runs the subcommand with the given arguments.
+	// It returns the output of the command, without leading and trailing whitespaces.
+	Execute(subcommand string, args ...string) (string, error)
+}
+
+// ExecExecutor runs the snapctl binary.
+// It is the default executor and works only inside a snap environment.
+type ExecExecutor struct{}
+
+// Execute runs 'snapctl <subcommand> <args>...'
+// The returned error includes the combined output of the failed command.
+func (ExecExecutor) Execute(subcommand string, args ...string) (string, error) {
+	args = append([]string{subcommand}, args...)
+
+	output, err := exec.Command("snapctl", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, output)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+var executor Executor = ExecExecutor{}
+
+// SetExecutor replaces the executor used for running all snapctl commands.
+// It returns the previous executor, to allow restoring it later.
+// This is useful for testing hooks outside a snap environment.
+// This function is NOT thread-safe. It should not be called concurrently with
+// the other functions of this package.
+func SetExecutor(e Executor) (previous Executor) {
+	previous = executor
+	executor = e
+	return previous
+}
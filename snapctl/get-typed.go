@@ -0,0 +1,112 @@
+package snapctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UnsetError is returned by typed getters when an option is not set
+type UnsetError struct {
+	Key string
+}
+
+func (e *UnsetError) Error() string {
+	return fmt.Sprintf("option '%s' is not set", e.Key)
+}
+
+// IsUnset returns true if the error is or wraps an *UnsetError
+func IsUnset(err error) bool {
+	var unsetErr *UnsetError
+	return errors.As(err, &unsetErr)
+}
+
+// TypeError is returned by typed getters when the value of an option
+// cannot be decoded into the requested type
+type TypeError struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("unexpected value for option '%s': %s: %s", e.Key, e.Value, e.Err)
+}
+
+func (e *TypeError) Unwrap() error {
+	return e.Err
+}
+
+// GetInto reads config options in strict mode and decodes them into v,
+// similar to json.Unmarshal.
+// With one key, the value of the option is decoded.
+// With multiple keys, a JSON object with the keys as field names is decoded.
+// It returns an *UnsetError if any of the options is not set.
+// It returns a *TypeError if the value cannot be decoded into v.
+func GetInto(v interface{}, keys ...string) error {
+	if len(keys) == 0 {
+		return errors.New("no keys given")
+	}
+
+	output, err := Get(keys...).Strict().Run()
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 1 {
+		if output == "null" {
+			return &UnsetError{Key: keys[0]}
+		}
+		return decode(keys[0], output, v)
+	}
+
+	// strict mode omits the unset keys from the document
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		return fmt.Errorf("unexpected snapctl output: %s", err)
+	}
+	for _, key := range keys {
+		if value, found := doc[key]; !found || bytes.Equal(value, []byte("null")) {
+			return &UnsetError{Key: key}
+		}
+	}
+	return decode(strings.Join(keys, " "), output, v)
+}
+
+func decode(key, value string, v interface{}) error {
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return &TypeError{Key: key, Value: value, Err: err}
+	}
+	return nil
+}
+
+// GetString reads a config option of type string.
+// Unlike Get, it distinguishes between an unset option and an empty string.
+func GetString(key string) (string, error) {
+	var s string
+	err := GetInto(&s, key)
+	return s, err
+}
+
+// GetBool reads a config option of type bool
+func GetBool(key string) (bool, error) {
+	var b bool
+	err := GetInto(&b, key)
+	return b, err
+}
+
+// GetInt reads a config option of type integer
+func GetInt(key string) (int, error) {
+	var i int
+	err := GetInto(&i, key)
+	return i, err
+}
+
+// GetStringSlice reads a config option of type array of strings
+func GetStringSlice(key string) ([]string, error) {
+	var s []string
+	err := GetInto(&s, key)
+	return s, err
+}
@@ -0,0 +1,108 @@
+package snapctl_test
+
+import (
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTyped(t *testing.T) {
+	fake := snapctltest.Install(t)
+	fake.SetConfig("string", "text")
+	fake.SetConfig("empty", "")
+	fake.SetConfig("bool", true)
+	fake.SetConfig("int", 8080)
+	fake.SetConfig("float", 1.5)
+	fake.SetConfig("strings", []string{"a", "b"})
+	fake.SetConfig("object", map[string]interface{}{
+		"key":   "value",
+		"count": 2,
+	})
+
+	t.Run("GetInto", func(t *testing.T) {
+		t.Run("one", func(t *testing.T) {
+			var v struct {
+				Key   string `json:"key"`
+				Count int    `json:"count"`
+			}
+			require.NoError(t, snapctl.GetInto(&v, "object"))
+			require.Equal(t, "value", v.Key)
+			require.Equal(t, 2, v.Count)
+		})
+
+		t.Run("multiple", func(t *testing.T) {
+			var v struct {
+				String string `json:"string"`
+				Int    int    `json:"int"`
+			}
+			require.NoError(t, snapctl.GetInto(&v, "string", "int"))
+			require.Equal(t, "text", v.String)
+			require.Equal(t, 8080, v.Int)
+		})
+
+		t.Run("unset", func(t *testing.T) {
+			var v interface{}
+			err := snapctl.GetInto(&v, "unknown")
+			require.Error(t, err)
+			require.True(t, snapctl.IsUnset(err))
+
+			err = snapctl.GetInto(&v, "string", "unknown")
+			require.Error(t, err)
+			var unsetErr *snapctl.UnsetError
+			require.ErrorAs(t, err, &unsetErr)
+			require.Equal(t, "unknown", unsetErr.Key)
+		})
+
+		t.Run("reject no keys", func(t *testing.T) {
+			var v interface{}
+			require.Error(t, snapctl.GetInto(&v))
+		})
+	})
+
+	t.Run("GetString", func(t *testing.T) {
+		s, err := snapctl.GetString("string")
+		require.NoError(t, err)
+		require.Equal(t, "text", s)
+
+		s, err = snapctl.GetString("empty")
+		require.NoError(t, err)
+		require.Equal(t, "", s)
+
+		_, err = snapctl.GetString("unknown")
+		require.True(t, snapctl.IsUnset(err))
+	})
+
+	t.Run("GetBool", func(t *testing.T) {
+		b, err := snapctl.GetBool("bool")
+		require.NoError(t, err)
+		require.True(t, b)
+
+		_, err = snapctl.GetBool("string")
+		var typeErr *snapctl.TypeError
+		require.ErrorAs(t, err, &typeErr)
+		require.Equal(t, "string", typeErr.Key)
+		require.Equal(t, `"text"`, typeErr.Value)
+	})
+
+	t.Run("GetInt", func(t *testing.T) {
+		i, err := snapctl.GetInt("int")
+		require.NoError(t, err)
+		require.Equal(t, 8080, i)
+
+		_, err = snapctl.GetInt("float")
+		var typeErr *snapctl.TypeError
+		require.ErrorAs(t, err, &typeErr)
+	})
+
+	t.Run("GetStringSlice", func(t *testing.T) {
+		s, err := snapctl.GetStringSlice("strings")
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, s)
+
+		_, err = snapctl.GetStringSlice("string")
+		var typeErr *snapctl.TypeError
+		require.ErrorAs(t, err, &typeErr)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"bufio"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -20,11 +21,116 @@ type services struct {
 	validators []func() error
 }
 
-// service status object
-type service struct {
+// ServiceState is the Startup or Current state of a service
+type ServiceState string
+
+const (
+	// StateEnabled is the Startup state of services which start on boot
+	StateEnabled ServiceState = "enabled"
+	// StateDisabled is the Startup state of services which don't start on boot
+	StateDisabled ServiceState = "disabled"
+	// StateActive is the Current state of running services
+	StateActive ServiceState = "active"
+	// StateInactive is the Current state of stopped services
+	StateInactive ServiceState = "inactive"
+	// StateUnknown is any state not known to this package, e.g. activating
+	StateUnknown ServiceState = "unknown"
+)
+
+// Notes of snapctl services
+const (
+	NoteSocketActivated = "socket-activated"
+	NoteTimerActivated  = "timer-activated"
+	NoteDBusActivated   = "dbus-activated"
+	NoteUser            = "user"
+)
+
+// ServiceStatus is the status of a service, as listed by snapctl services
+type ServiceStatus struct {
+	// Name is the full name of the service, e.g. edgexfoundry.core-data
+	Name string
+	// Snap is the name of the snap instance, e.g. edgexfoundry
+	Snap string
+	// App is the short name of the service, e.g. core-data
+	App string
+	// Startup is StateEnabled, StateDisabled, or StateUnknown
+	Startup ServiceState
+	// Current is StateActive, StateInactive, or StateUnknown
+	Current ServiceState
+	// Enabled is true if Startup is StateEnabled
 	Enabled bool
-	Active  bool
-	Notes   string
+	// Active is true if Current is StateActive
+	Active bool
+	// Notes is the Notes column, e.g. "timer-activated" or "-"
+	Notes string
+}
+
+// NoteList returns the individual notes, e.g. socket-activated or
+// install-mode, or nil if there are none
+func (s ServiceStatus) NoteList() []string {
+	var notes []string
+	for _, note := range strings.Split(s.Notes, ",") {
+		if note = strings.TrimSpace(note); note != "" && note != "-" {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// HasNote returns true if the notes include the given note
+func (s ServiceStatus) HasNote(note string) bool {
+	for _, n := range s.NoteList() {
+		if n == note {
+			return true
+		}
+	}
+	return false
+}
+
+// SocketActivated returns true if the service is started by a socket
+func (s ServiceStatus) SocketActivated() bool {
+	return s.HasNote(NoteSocketActivated)
+}
+
+// TimerActivated returns true if the service is started by a timer
+func (s ServiceStatus) TimerActivated() bool {
+	return s.HasNote(NoteTimerActivated)
+}
+
+// ServiceStatuses maps the full names of services to their status
+type ServiceStatuses map[string]ServiceStatus
+
+// ActiveApps returns the short names of the active services, sorted
+func (s ServiceStatuses) ActiveApps() []string {
+	return s.apps(func(status ServiceStatus) bool { return status.Current == StateActive })
+}
+
+// InactiveApps returns the short names of the inactive services, sorted.
+// Services in an unknown state are excluded.
+func (s ServiceStatuses) InactiveApps() []string {
+	return s.apps(func(status ServiceStatus) bool { return status.Current == StateInactive })
+}
+
+// EnabledApps returns the short names of the enabled services, sorted
+func (s ServiceStatuses) EnabledApps() []string {
+	return s.apps(func(status ServiceStatus) bool { return status.Startup == StateEnabled })
+}
+
+// DisabledApps returns the short names of the disabled services, sorted.
+// Services in an unknown state are excluded.
+func (s ServiceStatuses) DisabledApps() []string {
+	return s.apps(func(status ServiceStatus) bool { return status.Startup == StateDisabled })
+}
+
+func (s ServiceStatuses) apps(filter func(ServiceStatus) bool) []string {
+	var apps []string
+	for _, status := range s {
+		if filter(status) {
+			apps = append(apps, status.App)
+		}
+	}
+	sort.Strings(apps)
+	return apps
 }
 
 // Services lists information about the services
@@ -46,7 +152,7 @@ func Services(name ...string) (cmd services) {
 }
 
 // Run executes the services command
-func (cmd services) Run() (map[string]service, error) {
+func (cmd services) Run() (ServiceStatuses, error) {
 	// validate all input
 	for _, validate := range cmd.validators {
 		if err := validate(); err != nil {
@@ -68,45 +174,55 @@ func (cmd services) Run() (map[string]service, error) {
 	return cmd.parseOutput(output)
 }
 
-func (cmd services) parseOutput(output string) (map[string]service, error) {
+var columnSeparator = regexp.MustCompile("[[:space:]]+")
+
+func (cmd services) parseOutput(output string) (ServiceStatuses, error) {
 	scanner := bufio.NewScanner(strings.NewReader(output))
 
 	// throw away the header:
 	// Service   Startup   Current   Notes
 	scanner.Scan()
 
-	services := make(map[string]service)
+	services := make(ServiceStatuses)
 	for scanner.Scan() {
-		line := scanner.Text()
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
 
 		// Split by whitespaces up to four parts.
 		// The last part is for notes which may contain spaces in itself.
-		cells := regexp.MustCompile("[[:space:]]+").Split(line, 4)
+		cells := columnSeparator.Split(line, 4)
 		if len(cells) != 4 {
 			return nil, fmt.Errorf("unexpected snapctl output: expected 4 columns, got: %d", len(cells))
 		}
 
 		serviceName := cells[0]
-		startup := cells[1]
-		current := cells[2]
-		notes := cells[3]
-
-		// validate the Startup value
-		if startup != "enabled" && startup != "disabled" {
-			return nil, fmt.Errorf("unexpected snapctl output: expected Startup as enabled|disabled, got: %s", startup)
+		status := ServiceStatus{
+			Name:    serviceName,
+			Startup: parseState(cells[1], StateEnabled, StateDisabled),
+			Current: parseState(cells[2], StateActive, StateInactive),
+			Notes:   cells[3],
 		}
-
-		// validate the Current value
-		if current != "active" && current != "inactive" {
-			return nil, fmt.Errorf("unexpected snapctl output: expected Current as active|inactive, got: %s", startup)
+		status.Snap, status.App = serviceName, serviceName
+		if i := strings.Index(serviceName, "."); i != -1 {
+			status.Snap, status.App = serviceName[:i], serviceName[i+1:]
 		}
+		status.Enabled = status.Startup == StateEnabled
+		status.Active = status.Current == StateActive
 
-		services[serviceName] = service{
-			Enabled: startup == "enabled",
-			Active:  current == "active",
-			Notes:   notes,
-		}
+		services[serviceName] = status
 	}
 
-	return services, nil
+	return services, scanner.Err()
+}
+
+// parseState returns the state if it is one of the known states, or StateUnknown
+func parseState(value string, known ...ServiceState) ServiceState {
+	for _, state := range known {
+		if ServiceState(value) == state {
+			return state
+		}
+	}
+	return StateUnknown
 }
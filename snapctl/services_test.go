@@ -63,3 +63,51 @@ func TestServices(t *testing.T) {
 
 	})
 }
+
+type outputExecutor string
+
+func (e outputExecutor) Execute(subcommand string, args ...string) (string, error) {
+	return string(e), nil
+}
+
+func TestServicesStatus(t *testing.T) {
+	previous := snapctl.SetExecutor(outputExecutor(`Service                          Startup   Current     Notes
+edgexfoundry.core-data           enabled   active      -
+edgexfoundry.core-metadata       disabled  inactive    -
+edgexfoundry.support-scheduler   enabled   activating  timer-activated
+edgexfoundry.security-proxy      unknown   active      socket-activated, user`))
+	t.Cleanup(func() { snapctl.SetExecutor(previous) })
+
+	services, err := snapctl.Services().Run()
+	require.NoError(t, err)
+	require.Len(t, services, 4)
+
+	require.Equal(t, snapctl.ServiceStatus{
+		Name:    "edgexfoundry.core-data",
+		Snap:    "edgexfoundry",
+		App:     "core-data",
+		Startup: snapctl.StateEnabled,
+		Current: snapctl.StateActive,
+		Enabled: true,
+		Active:  true,
+		Notes:   "-",
+	}, services["edgexfoundry.core-data"])
+	require.Nil(t, services["edgexfoundry.core-data"].NoteList())
+
+	scheduler := services["edgexfoundry.support-scheduler"]
+	require.Equal(t, snapctl.StateUnknown, scheduler.Current)
+	require.False(t, scheduler.Active)
+	require.True(t, scheduler.TimerActivated())
+	require.False(t, scheduler.SocketActivated())
+
+	proxy := services["edgexfoundry.security-proxy"]
+	require.Equal(t, snapctl.StateUnknown, proxy.Startup)
+	require.Equal(t, []string{"socket-activated", "user"}, proxy.NoteList())
+	require.True(t, proxy.SocketActivated())
+	require.True(t, proxy.HasNote(snapctl.NoteUser))
+
+	require.Equal(t, []string{"core-data", "security-proxy"}, services.ActiveApps())
+	require.Equal(t, []string{"core-metadata"}, services.InactiveApps())
+	require.Equal(t, []string{"core-data", "support-scheduler"}, services.EnabledApps())
+	require.Equal(t, []string{"core-metadata"}, services.DisabledApps())
+}
@@ -0,0 +1,73 @@
+package snapctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// validKeySegment is the grammar of option names defined by snapd.
+// Keys are made of one or more such names, separated by dots.
+var validKeySegment = regexp.MustCompile("^(?:[a-z0-9]+-?)*[a-z](?:-?[a-z0-9])*$")
+
+// validateKey checks the key against the option name grammar of snapd
+func validateKey(key string) error {
+	for _, segment := range strings.Split(key, ".") {
+		if !validKeySegment.MatchString(segment) {
+			return fmt.Errorf("invalid option name: '%s'. "+
+				"Names must contain only lowercase letters, digits and dashes, "+
+				"start and end with a letter or digit, and contain at least one letter", key)
+		}
+	}
+	return nil
+}
+
+// SetValue encodes the value as JSON and sets it to the config option.
+// Any value supported by json.Marshal can be set, including nested objects and arrays.
+// A nil value unsets the option.
+func SetValue(key string, v interface{}) error {
+	return SetMap(map[string]interface{}{key: v})
+}
+
+// SetMap encodes the values as JSON and sets them to the config options,
+// all in a single snapctl call.
+// See SetValue.
+func SetMap(values map[string]interface{}) error {
+	if len(values) == 0 {
+		return errors.New("no values given")
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if err := validateKey(key); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var keyValues []string
+	for _, key := range keys {
+		value, err := encode(values[key])
+		if err != nil {
+			return fmt.Errorf("error encoding value of '%s': %s", key, err)
+		}
+		keyValues = append(keyValues, key, value)
+	}
+
+	return Set(keyValues...).Document().Run()
+}
+
+func encode(v interface{}) (string, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
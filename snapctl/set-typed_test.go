@@ -0,0 +1,79 @@
+package snapctl_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetTyped(t *testing.T) {
+	t.Run("SetValue", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+
+		t.Run("string", func(t *testing.T) {
+			// should be set as string, even though it is valid JSON
+			require.NoError(t, snapctl.SetValue("key", "true"))
+			value, _ := fake.Config("key")
+			require.Equal(t, "true", value)
+		})
+
+		t.Run("struct", func(t *testing.T) {
+			type tls struct {
+				Enabled bool     `json:"enabled"`
+				Ports   []int    `json:"ports"`
+				Hosts   []string `json:"hosts"`
+			}
+			require.NoError(t, snapctl.SetValue("http.tls", tls{
+				Enabled: true,
+				Ports:   []int{443, 8443},
+				Hosts:   []string{"<local>"},
+			}))
+
+			var v tls
+			require.NoError(t, snapctl.GetInto(&v, "http.tls"))
+			require.Equal(t, tls{true, []int{443, 8443}, []string{"<local>"}}, v)
+		})
+
+		t.Run("nil", func(t *testing.T) {
+			fake.SetConfig("key", "value")
+			require.NoError(t, snapctl.SetValue("key", nil))
+			_, found := fake.Config("key")
+			require.False(t, found)
+		})
+
+		t.Run("reject invalid key", func(t *testing.T) {
+			for _, key := range []string{"", "Key", "key_1", "key.", "-key", "key-", "key--1", "123", "bad key"} {
+				require.Error(t, snapctl.SetValue(key, "value"), key)
+			}
+			require.NoError(t, snapctl.SetValue("a-1.b2.3c", "value"))
+		})
+
+		t.Run("reject unsupported value", func(t *testing.T) {
+			require.Error(t, snapctl.SetValue("key", make(chan int)))
+		})
+	})
+
+	t.Run("SetMap", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+
+		require.NoError(t, snapctl.SetMap(map[string]interface{}{
+			"b":   map[string]int{"c": 1},
+			"a":   "text",
+			"d.e": []interface{}{"x", 2, false},
+		}))
+
+		// all in one call, in deterministic order
+		require.Equal(t, []snapctltest.Call{{
+			Subcommand: "set",
+			Args:       []string{"-t", `a="text"`, `b={"c":1}`, `d.e=["x",2,false]`},
+		}}, fake.Calls())
+
+		value, _ := fake.Config("d.e")
+		require.Equal(t, []interface{}{"x", json.Number("2"), false}, value)
+
+		require.Error(t, snapctl.SetMap(nil))
+	})
+}
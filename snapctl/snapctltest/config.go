@@ -0,0 +1,318 @@
+package snapctltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// validKey is the grammar of each dot-separated segment of an option key, as defined by snapd
+var validKey = regexp.MustCompile("^(?:[a-z0-9]+-?)*[a-z](?:-?[a-z0-9])*$")
+
+// SetConfig sets a config option, bypassing snapctl.
+// The key may be a dotted path. The value should be JSON-serializable.
+// A nil value unsets the option. Like snapd, nil values nested in objects
+// are removed rather than stored.
+func (f *Fake) SetConfig(key string, value interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := setPath(f.config, key, normalize(value)); err != nil {
+		panic(err)
+	}
+}
+
+// Config returns the value of a config option, bypassing snapctl.
+// The key may be a dotted path.
+// Objects are returned as map[string]interface{} and numbers as json.Number.
+func (f *Fake) Config(key string) (value interface{}, found bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return getPath(f.config, key)
+}
+
+// SetAttribute sets an interface attribute of a plug of this snap.
+// When remote is true, the attribute is set on the slot connected to the plug instead.
+// The attributes are served by 'snapctl get [--slot] :<plug>'.
+func (f *Fake) SetAttribute(name string, remote bool, key string, value interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	attrs := f.attributes(name, remote)
+	if err := setPath(attrs, key, normalize(value)); err != nil {
+		panic(err)
+	}
+}
+
+// Attribute returns the value of an interface attribute of a plug of this snap
+func (f *Fake) Attribute(name, key string) (value interface{}, found bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return getPath(f.attributes(name, false), key)
+}
+
+func (f *Fake) attributes(name string, remote bool) map[string]interface{} {
+	all := f.plugAttrs
+	if remote {
+		all = f.slotAttrs
+	}
+	if all[name] == nil {
+		all[name] = make(map[string]interface{})
+	}
+	return all[name]
+}
+
+// get emulates:
+// get [get-OPTIONS] [:<plug|slot>] [<keys>...]
+func (f *Fake) get(args []string) (string, error) {
+	options, iface, keys := parseArgs(args)
+
+	typed, document := options["-t"], options["-d"]
+	if typed && document {
+		return "", errors.New("cannot use -d and -t together")
+	}
+	if (options["--plug"] || options["--slot"]) && iface == "" {
+		return "", errors.New("cannot use --plug or --slot without <snap>:<plug|slot> argument")
+	}
+	if len(keys) == 0 {
+		if iface != "" {
+			return "", errors.New("get which attribute?")
+		}
+		return "", errors.New("get which option?")
+	}
+
+	tree := f.config
+	if iface != "" {
+		tree = f.attributes(iface, options["--slot"])
+	}
+
+	patch := make(map[string]interface{})
+	for _, key := range keys {
+		value, found := getPath(tree, key)
+		if !found && iface != "" {
+			return "", fmt.Errorf("unknown attribute %q", key)
+		}
+		if found {
+			patch[key] = value
+		}
+	}
+
+	var output interface{} = patch
+	if !document && len(keys) == 1 {
+		output = patch[keys[0]]
+	}
+
+	if typed && output == nil {
+		return "null", nil
+	}
+	if s, ok := output.(string); ok && !typed {
+		return s, nil
+	}
+	if output == nil {
+		return "", nil
+	}
+	return marshal(output)
+}
+
+// set emulates:
+// set [set-OPTIONS] [:<plug|slot>] [key=value...]
+func (f *Fake) set(args []string) error {
+	options, iface, keyValues := parseArgs(args)
+
+	if options["-s"] && options["-t"] {
+		return errors.New("cannot use -s and -t together")
+	}
+	if len(keyValues) == 0 {
+		return errors.New("set which option?")
+	}
+
+	tree := f.config
+	if iface != "" {
+		tree = f.attributes(iface, false)
+	}
+
+	// validate everything before applying, like a transaction
+	type patch struct {
+		key   string
+		value interface{}
+	}
+	var patches []patch
+	for _, kv := range keyValues {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 1 && strings.HasSuffix(kv, "!") {
+			patches = append(patches, patch{key: strings.TrimSuffix(kv, "!")})
+			continue
+		}
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid parameter: %q (want key=value)", kv)
+		}
+
+		key, raw := parts[0], parts[1]
+		var value interface{}
+		switch {
+		case options["-s"]:
+			value = raw
+		case options["-t"]:
+			v, err := unmarshal(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse JSON: %w", err)
+			}
+			value = v
+		default:
+			v, err := unmarshal(raw)
+			if err != nil {
+				// not JSON, so take it as a string
+				v = raw
+			}
+			value = v
+		}
+		patches = append(patches, patch{key, value})
+	}
+
+	for _, p := range patches {
+		if err := validateKey(p.key); err != nil {
+			return err
+		}
+	}
+	for _, p := range patches {
+		if err := setPath(tree, p.key, p.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unset emulates:
+// unset [ConfKeys...]
+func (f *Fake) unset(keys []string) error {
+	if len(keys) == 0 {
+		return errors.New("unset which option?")
+	}
+	for _, key := range keys {
+		if err := validateKey(key); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if err := setPath(f.config, key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateKey(key string) error {
+	for _, segment := range strings.Split(key, ".") {
+		if !validKey.MatchString(segment) {
+			return fmt.Errorf("invalid option name: %q", key)
+		}
+	}
+	return nil
+}
+
+func getPath(tree map[string]interface{}, key string) (interface{}, bool) {
+	var value interface{} = tree
+	for _, segment := range strings.Split(key, ".") {
+		subtree, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = subtree[segment]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setPath sets the value at the dotted path, creating intermediate objects.
+// A nil value removes the entry, and so do nil values nested in objects.
+func setPath(tree map[string]interface{}, key string, value interface{}) error {
+	value = purgeNulls(value)
+
+	path := strings.Split(key, ".")
+	for i, segment := range path[:len(path)-1] {
+		v, exists := tree[segment]
+		if !exists {
+			if value == nil {
+				return nil
+			}
+			v = make(map[string]interface{})
+			tree[segment] = v
+		}
+		subtree, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("option %q is not a map", strings.Join(path[:i+1], "."))
+		}
+		tree = subtree
+	}
+
+	last := path[len(path)-1]
+	if value == nil {
+		delete(tree, last)
+	} else {
+		tree[last] = value
+	}
+	return nil
+}
+
+// purgeNulls removes the null entries of objects, recursively,
+// since snapd doesn't store them
+func purgeNulls(value interface{}) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for k, v := range object {
+		if v == nil {
+			delete(object, k)
+		} else {
+			object[k] = purgeNulls(v)
+		}
+	}
+	return object
+}
+
+// normalize converts a Go value to the generic form used for storage
+func normalize(value interface{}) interface{} {
+	b, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Sprintf("value is not JSON-serializable: %v", err))
+	}
+	v, err := unmarshal(string(b))
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func unmarshal(s string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	// keep numbers as they are, like snapd does
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value: %s", s)
+	}
+	return v, nil
+}
+
+// marshal formats the value like snapctl
+func marshal(v interface{}) (string, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
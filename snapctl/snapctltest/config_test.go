@@ -0,0 +1,208 @@
+package snapctltest_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeConfig(t *testing.T) {
+	t.Run("get", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+		fake.SetConfig("a", map[string]interface{}{
+			"b": "value",
+			"c": 1,
+		})
+
+		t.Run("nested", func(t *testing.T) {
+			value, err := snapctl.Get("a.b").Run()
+			require.NoError(t, err)
+			require.Equal(t, "value", value)
+		})
+
+		t.Run("object", func(t *testing.T) {
+			value, err := snapctl.Get("a").Run()
+			require.NoError(t, err)
+			require.Equal(t, "{\n\t\"b\": \"value\",\n\t\"c\": 1\n}", value)
+		})
+
+		t.Run("multiple", func(t *testing.T) {
+			value, err := snapctl.Get("a.b", "a.c").Run()
+			require.NoError(t, err)
+			require.JSONEq(t, `{"a.b":"value","a.c":1}`, value)
+		})
+
+		t.Run("unset", func(t *testing.T) {
+			value, err := snapctl.Get("x").Run()
+			require.NoError(t, err)
+			require.Equal(t, "", value)
+		})
+
+		t.Run("document", func(t *testing.T) {
+			value, err := snapctl.Get("a.b").Document().Run()
+			require.NoError(t, err)
+			require.JSONEq(t, `{"a.b":"value"}`, value)
+
+			value, err = snapctl.Get("x").Document().Run()
+			require.NoError(t, err)
+			require.Equal(t, `{}`, value)
+		})
+
+		t.Run("strict", func(t *testing.T) {
+			value, err := snapctl.Get("a.b").Strict().Run()
+			require.NoError(t, err)
+			require.Equal(t, `"value"`, value)
+
+			value, err = snapctl.Get("x").Strict().Run()
+			require.NoError(t, err)
+			require.Equal(t, "null", value)
+		})
+
+		t.Run("reject document and strict", func(t *testing.T) {
+			_, err := snapctl.Get("a").Document().Strict().Run()
+			require.Error(t, err)
+		})
+
+		t.Run("reject no keys", func(t *testing.T) {
+			_, err := snapctl.Get().Run()
+			require.Error(t, err)
+		})
+	})
+
+	t.Run("set", func(t *testing.T) {
+		t.Run("json or string", func(t *testing.T) {
+			fake := snapctltest.Install(t)
+
+			require.NoError(t, snapctl.Set(
+				"a", "text",
+				"b", "true",
+				"c", "12345678901234567890",
+				"d.e", `{"f":[1,2]}`,
+			).Run())
+
+			value, _ := fake.Config("a")
+			require.Equal(t, "text", value)
+			value, _ = fake.Config("b")
+			require.Equal(t, true, value)
+			value, _ = fake.Config("c")
+			require.Equal(t, json.Number("12345678901234567890"), value)
+			value, _ = fake.Config("d.e.f")
+			require.Equal(t, []interface{}{json.Number("1"), json.Number("2")}, value)
+		})
+
+		t.Run("string", func(t *testing.T) {
+			fake := snapctltest.Install(t)
+
+			require.NoError(t, snapctl.Set("a", "true").String().Run())
+			value, _ := fake.Config("a")
+			require.Equal(t, "true", value)
+		})
+
+		t.Run("strict", func(t *testing.T) {
+			fake := snapctltest.Install(t)
+
+			require.NoError(t, snapctl.Set("a", `{"b":"c"}`).Document().Run())
+			value, _ := fake.Config("a.b")
+			require.Equal(t, "c", value)
+
+			require.Error(t, snapctl.Set("a", "not json").Document().Run())
+		})
+
+		t.Run("unset with exclamation mark", func(t *testing.T) {
+			fake := snapctltest.Install(t)
+			fake.SetConfig("a.b", "c")
+
+			_, err := fake.Execute("set", "a.b!")
+			require.NoError(t, err)
+			_, found := fake.Config("a.b")
+			require.False(t, found)
+		})
+
+		t.Run("unset with null", func(t *testing.T) {
+			fake := snapctltest.Install(t)
+			fake.SetConfig("a", "b")
+
+			require.NoError(t, snapctl.Set("a", "null").Run())
+			_, found := fake.Config("a")
+			require.False(t, found)
+		})
+
+		t.Run("purge nested nulls", func(t *testing.T) {
+			fake := snapctltest.Install(t)
+
+			_, err := fake.Execute("set", "-t", `a={"b":null,"c":{"d":null,"e":1},"f":[null]}`)
+			require.NoError(t, err)
+			value, err := fake.Execute("get", "-d", "a")
+			require.NoError(t, err)
+			require.JSONEq(t, `{"a":{"c":{"e":1},"f":[null]}}`, value)
+
+			fake.SetConfig("g", map[string]interface{}{"h": nil})
+			_, found := fake.Config("g.h")
+			require.False(t, found)
+		})
+
+		t.Run("reject invalid key", func(t *testing.T) {
+			fake := snapctltest.Install(t)
+
+			for _, key := range []string{"A", "a_b", "a..b", "-a", "a-", "1"} {
+				_, err := fake.Execute("set", key+"=value")
+				require.Error(t, err, key)
+			}
+		})
+
+		t.Run("reject nesting in non-object", func(t *testing.T) {
+			fake := snapctltest.Install(t)
+			fake.SetConfig("a", "b")
+
+			require.Error(t, snapctl.Set("a.b", "c").Run())
+		})
+
+		t.Run("reject string and strict", func(t *testing.T) {
+			snapctltest.Install(t)
+
+			require.Error(t, snapctl.Set("a", "b").String().Document().Run())
+		})
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+		fake.SetConfig("a.b", "c")
+		fake.SetConfig("a.d", "e")
+
+		require.NoError(t, snapctl.Unset("a.b", "x.y").Run())
+		_, found := fake.Config("a.b")
+		require.False(t, found)
+		_, found = fake.Config("a.d")
+		require.True(t, found)
+	})
+
+	t.Run("interface attributes", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+		fake.SetAttribute("test-plug", false, "path", "/dev/ttyS0")
+		fake.SetAttribute("test-plug", true, "path", "/dev/ttyS1")
+
+		value, err := snapctl.Get("path").Interface("test-plug").Run()
+		require.NoError(t, err)
+		require.Equal(t, "/dev/ttyS0", value)
+
+		// attributes of the connected slot
+		value, err = fake.Execute("get", "--slot", ":test-plug", "path")
+		require.NoError(t, err)
+		require.Equal(t, "/dev/ttyS1", value)
+
+		_, err = snapctl.Get("x").Interface("test-plug").Run()
+		require.Error(t, err, "unknown attribute")
+
+		require.NoError(t, snapctl.Set("baud-rate", "9600").Interface("test-plug").Run())
+		attr, found := fake.Attribute("test-plug", "baud-rate")
+		require.True(t, found)
+		require.Equal(t, json.Number("9600"), attr)
+
+		// config options should not be affected
+		_, found = fake.Config("baud-rate")
+		require.False(t, found)
+	})
+}
@@ -0,0 +1,169 @@
+// Package snapctltest provides an in-memory snapctl executor
+// for testing snap hooks outside of a snap environment.
+//
+// The fake emulates the behavior of snapctl for config options, interface
+// attributes, services and connections. The state can be prepared and
+// inspected directly, bypassing snapctl.
+//
+// Usage:
+//
+//	func TestConfigure(t *testing.T) {
+//		fake := snapctltest.Install(t)
+//		fake.SetConfig("autostart", true)
+//		fake.AddService("my-snap.my-app", false, false)
+//
+//		// code under test, calling snapctl wrappers
+//
+//		require.Contains(t, fake.Calls(), snapctltest.Call{...})
+//	}
+package snapctltest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+)
+
+// Call is a snapctl command received by the fake
+type Call struct {
+	Subcommand string
+	Args       []string
+}
+
+// String returns the call as it would appear on the command line, without 'snapctl'
+func (c Call) String() string {
+	return strings.Join(append([]string{c.Subcommand}, c.Args...), " ")
+}
+
+// Fake is an in-memory implementation of snapctl.Executor.
+// It records all calls and serves config options from a tree in memory.
+type Fake struct {
+	mu        sync.Mutex
+	calls     []Call
+	config    map[string]interface{}
+	plugAttrs map[string]map[string]interface{}
+	slotAttrs map[string]map[string]interface{}
+	services  map[string]*serviceState
+	connected map[string]bool
+	failures  map[string]error
+}
+
+// New returns a fake with no config options, services, or interfaces
+func New() *Fake {
+	return &Fake{
+		config:    make(map[string]interface{}),
+		plugAttrs: make(map[string]map[string]interface{}),
+		slotAttrs: make(map[string]map[string]interface{}),
+		services:  make(map[string]*serviceState),
+		connected: make(map[string]bool),
+		failures:  make(map[string]error),
+	}
+}
+
+// Install creates a new fake and sets it as the executor of the snapctl package.
+// The previous executor is restored when the test and all its subtests complete.
+func Install(t testing.TB) *Fake {
+	f := New()
+	previous := snapctl.SetExecutor(f)
+	t.Cleanup(func() {
+		snapctl.SetExecutor(previous)
+	})
+	return f
+}
+
+// Calls returns the commands executed so far, in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]Call, len(f.calls))
+	copy(calls, f.calls)
+	return calls
+}
+
+// ResetCalls removes all the recorded calls
+func (f *Fake) ResetCalls() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+}
+
+// FailOn makes all subsequent calls of the subcommand return the given error.
+// A nil error removes the failure.
+func (f *Fake) FailOn(subcommand string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		delete(f.failures, subcommand)
+	} else {
+		f.failures[subcommand] = err
+	}
+}
+
+// Execute implements snapctl.Executor
+func (f *Fake) Execute(subcommand string, args ...string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{
+		Subcommand: subcommand,
+		Args:       append([]string(nil), args...),
+	})
+
+	if err := f.failures[subcommand]; err != nil {
+		return "", err
+	}
+
+	var output string
+	var err error
+	switch subcommand {
+	case "get":
+		output, err = f.get(args)
+	case "set":
+		err = f.set(args)
+	case "unset":
+		err = f.unset(args)
+	case "services":
+		output, err = f.listServices(args)
+	case "start":
+		err = f.start(args)
+	case "stop":
+		err = f.stop(args)
+	case "restart":
+		err = f.restart(args)
+	case "is-connected":
+		err = f.isConnected(args)
+	default:
+		err = fmt.Errorf("unknown command %q", subcommand)
+	}
+	if err == errNotConnected {
+		return "", err
+	} else if err != nil {
+		// snapctl prints errors to stderr, with this prefix
+		return "", errors.New("error: " + err.Error())
+	}
+	return output, nil
+}
+
+// parseArgs separates the options (-x or --x), plug/slot name (:x),
+// and positional arguments
+func parseArgs(args []string) (options map[string]bool, iface string, positional []string) {
+	options = make(map[string]bool)
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "-"):
+			options[arg] = true
+		case strings.HasPrefix(arg, ":") && iface == "" && len(positional) == 0:
+			iface = strings.TrimPrefix(arg, ":")
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return
+}
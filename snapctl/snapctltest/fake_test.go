@@ -0,0 +1,61 @@
+package snapctltest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	t.Run("record calls", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+		fake.AddService("snap.app", false, false)
+		fake.AddService("snap.app2", false, false)
+
+		require.NoError(t, snapctl.Start("snap.app").Enable().Run())
+		require.NoError(t, snapctl.Stop("snap.app2").Run())
+
+		require.Equal(t, []snapctltest.Call{
+			{Subcommand: "start", Args: []string{"--enable", "snap.app"}},
+			{Subcommand: "stop", Args: []string{"snap.app2"}},
+		}, fake.Calls())
+		require.Equal(t, "start --enable snap.app", fake.Calls()[0].String())
+
+		fake.ResetCalls()
+		require.Empty(t, fake.Calls())
+	})
+
+	t.Run("fail on", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+		fake.FailOn("get", errors.New("failed"))
+
+		_, err := snapctl.Get("key").Run()
+		require.EqualError(t, err, "failed")
+
+		fake.FailOn("get", nil)
+		_, err = snapctl.Get("key").Run()
+		require.NoError(t, err)
+	})
+
+	t.Run("reject unknown command", func(t *testing.T) {
+		fake := snapctltest.New()
+
+		_, err := fake.Execute("reboot")
+		require.Error(t, err)
+	})
+
+	t.Run("restore executor", func(t *testing.T) {
+		var fake *snapctltest.Fake
+		t.Run("install", func(t *testing.T) {
+			fake = snapctltest.Install(t)
+		})
+
+		// the fake should no longer be set
+		previous := snapctl.SetExecutor(snapctl.ExecExecutor{})
+		snapctl.SetExecutor(previous)
+		require.NotEqual(t, fake, previous)
+	})
+}
@@ -0,0 +1,200 @@
+package snapctltest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type serviceState struct {
+	enabled bool
+	active  bool
+}
+
+// errNotConnected is returned by is-connected, which fails without output
+// when the plug or slot is not connected
+var errNotConnected = errors.New("exit status 1")
+
+// AddService adds a service to the snap, or replaces an existing one.
+// The name must be the full service name, i.e. <snap>.<app>
+func (f *Fake) AddService(name string, enabled, active bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.Contains(name, ".") {
+		panic(fmt.Sprintf("service name must be <snap>.<app>, got: %s", name))
+	}
+	f.services[name] = &serviceState{
+		enabled: enabled,
+		active:  active,
+	}
+}
+
+// Service returns the status of a service
+func (f *Fake) Service(name string) (enabled, active, found bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s, found := f.services[name]
+	if !found {
+		return false, false, false
+	}
+	return s.enabled, s.active, true
+}
+
+// Connect marks a plug or slot of the snap as connected
+func (f *Fake) Connect(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.connected[name] = true
+}
+
+// Disconnect marks a plug or slot of the snap as disconnected
+func (f *Fake) Disconnect(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.connected, name)
+}
+
+// lookupServices returns the full names of the requested services, sorted.
+// Similar to snapctl, the snap name refers to all services of the snap.
+func (f *Fake) lookupServices(names []string) ([]string, error) {
+	found := make(map[string]bool)
+	for _, name := range names {
+		if _, ok := f.services[name]; ok {
+			found[name] = true
+			continue
+		}
+		var matched bool
+		for service := range f.services {
+			if strings.HasPrefix(service, name+".") {
+				found[service] = true
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("unknown service: %q", name)
+		}
+	}
+
+	var services []string
+	for service := range found {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// listServices emulates:
+// services [<service>...]
+func (f *Fake) listServices(names []string) (string, error) {
+	var services []string
+	if len(names) == 0 {
+		for service := range f.services {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+	} else {
+		var err error
+		services, err = f.lookupServices(names)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(services) == 0 {
+		return "", nil
+	}
+
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 5, 3, 2, ' ', 0)
+	fmt.Fprintln(w, "Service\tStartup\tCurrent\tNotes")
+	for _, name := range services {
+		s := f.services[name]
+		startup, current := "disabled", "inactive"
+		if s.enabled {
+			startup = "enabled"
+		}
+		if s.active {
+			current = "active"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, startup, current, "-")
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// start emulates:
+// start [start-OPTIONS] <service>...
+func (f *Fake) start(args []string) error {
+	options, _, names := parseArgs(args)
+	services, err := f.requireServices(names)
+	if err != nil {
+		return err
+	}
+	for _, name := range services {
+		f.services[name].active = true
+		if options["--enable"] {
+			f.services[name].enabled = true
+		}
+	}
+	return nil
+}
+
+// stop emulates:
+// stop [stop-OPTIONS] <service>...
+func (f *Fake) stop(args []string) error {
+	options, _, names := parseArgs(args)
+	services, err := f.requireServices(names)
+	if err != nil {
+		return err
+	}
+	for _, name := range services {
+		f.services[name].active = false
+		if options["--disable"] {
+			f.services[name].enabled = false
+		}
+	}
+	return nil
+}
+
+// restart emulates:
+// restart [restart-OPTIONS] <service>...
+func (f *Fake) restart(args []string) error {
+	_, _, names := parseArgs(args)
+	services, err := f.requireServices(names)
+	if err != nil {
+		return err
+	}
+	for _, name := range services {
+		f.services[name].active = true
+	}
+	return nil
+}
+
+func (f *Fake) requireServices(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, errors.New("the required argument `<service> (at least 1 argument)` was not provided")
+	}
+	return f.lookupServices(names)
+}
+
+// isConnected emulates:
+// is-connected [is-connected-OPTIONS] <plug|slot>
+func (f *Fake) isConnected(args []string) error {
+	_, _, names := parseArgs(args)
+	if len(names) != 1 {
+		return errors.New("the required argument `<plug|slot>` was not provided")
+	}
+	if !f.connected[names[0]] {
+		return errNotConnected
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package snapctltest_test
+
+import (
+	"testing"
+
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	snapName     = "test-snap"
+	testService  = snapName + ".test-service"
+	testService2 = snapName + ".test-service-2"
+)
+
+func TestFakeServices(t *testing.T) {
+	fake := snapctltest.Install(t)
+	fake.AddService(testService, true, true)
+	fake.AddService(testService2, false, false)
+
+	t.Run("list", func(t *testing.T) {
+		output, err := fake.Execute("services")
+		require.NoError(t, err)
+		require.Equal(t,
+			"Service                   Startup   Current   Notes\n"+
+				"test-snap.test-service    enabled   active    -\n"+
+				"test-snap.test-service-2  disabled  inactive  -",
+			output)
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		services, err := snapctl.Services().Run()
+		require.NoError(t, err)
+		require.Len(t, services, 2)
+		require.True(t, services[testService].Enabled)
+		require.True(t, services[testService].Active)
+		require.False(t, services[testService2].Enabled)
+		require.False(t, services[testService2].Active)
+
+		services, err = snapctl.Services(testService2).Run()
+		require.NoError(t, err)
+		require.Len(t, services, 1)
+	})
+
+	t.Run("reject unknown service", func(t *testing.T) {
+		_, err := snapctl.Services("unknown").Run()
+		require.Error(t, err)
+		require.Error(t, snapctl.Start("unknown").Run())
+	})
+
+	t.Run("start and stop", func(t *testing.T) {
+		require.NoError(t, snapctl.Start(testService2).Enable().Run())
+		enabled, active, _ := fake.Service(testService2)
+		require.True(t, enabled)
+		require.True(t, active)
+
+		require.NoError(t, snapctl.Stop(testService2).Run())
+		enabled, active, _ = fake.Service(testService2)
+		require.True(t, enabled)
+		require.False(t, active)
+
+		require.NoError(t, snapctl.Restart(testService2).Run())
+		_, active, _ = fake.Service(testService2)
+		require.True(t, active)
+	})
+
+	t.Run("snap name as all services", func(t *testing.T) {
+		require.NoError(t, snapctl.Stop(snapName).Disable().Run())
+
+		services, err := snapctl.Services().Run()
+		require.NoError(t, err)
+		for name, status := range services {
+			require.False(t, status.Enabled, name)
+			require.False(t, status.Active, name)
+		}
+	})
+}
+
+func TestFakeIsConnected(t *testing.T) {
+	fake := snapctltest.Install(t)
+
+	connected, err := snapctl.IsConnected("test-plug").Run()
+	require.NoError(t, err)
+	require.False(t, connected)
+
+	fake.Connect("test-plug")
+	connected, err = snapctl.IsConnected("test-plug").Run()
+	require.NoError(t, err)
+	require.True(t, connected)
+
+	fake.Disconnect("test-plug")
+	connected, err = snapctl.IsConnected("test-plug").Run()
+	require.NoError(t, err)
+	require.False(t, connected)
+}
@@ -0,0 +1,77 @@
+package snapctl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// The polling interval of WaitFor, doubled after each poll up to the maximum
+var (
+	waitInitialInterval = 100 * time.Millisecond
+	waitMaxInterval     = 2 * time.Second
+)
+
+// WaitFor polls the status of the services until all of them reach the state,
+// or until the context is done.
+// The state is one of StateActive, StateInactive, StateEnabled, or StateDisabled.
+// Services in an unknown state have not reached any state.
+//
+// It returns the sorted names of the services which have not reached the state,
+// along with the context's error if the context is done before they do,
+// or the error of getting the status of services.
+//
+// Inside hooks, snapd queues the changes requested via Start, Stop, and Restart
+// until the hook finishes. Waiting in a hook for the services to reach
+// the requested state can therefore only time out.
+// Use WaitFor from apps, e.g. a oneshot daemon, or outside of the snap.
+func WaitFor(ctx context.Context, state ServiceState, services ...string) (pending []string, err error) {
+	var reached func(ServiceStatus) bool
+	switch state {
+	case StateActive, StateInactive:
+		reached = func(s ServiceStatus) bool { return s.Current == state }
+	case StateEnabled, StateDisabled:
+		reached = func(s ServiceStatus) bool { return s.Startup == state }
+	default:
+		return nil, fmt.Errorf("unsupported state to wait for: %s", state)
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("empty services list")
+	}
+
+	pending = append([]string{}, services...)
+	sort.Strings(pending)
+
+	interval := waitInitialInterval
+	for {
+		statuses, err := Services(pending...).Run()
+		if err != nil {
+			return pending, fmt.Errorf("error getting status of services: %s", err)
+		}
+
+		var remaining []string
+		for _, service := range pending {
+			if !reached(statuses[service]) {
+				remaining = append(remaining, service)
+			}
+		}
+		pending = remaining
+		if len(pending) == 0 {
+			return nil, nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return pending, ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > waitMaxInterval {
+			interval = waitMaxInterval
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package snapctl_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl"
+	"github.com/canonical/edgex-snap-hooks/v3/snapctl/snapctltest"
+	"github.com/stretchr/testify/require"
+)
+
+// startingExecutor activates a service after a number of status queries
+type startingExecutor struct {
+	*snapctltest.Fake
+	service string
+	polls   int
+}
+
+func (e *startingExecutor) Execute(subcommand string, args ...string) (string, error) {
+	if subcommand == "services" {
+		if e.polls--; e.polls == 0 {
+			e.Fake.AddService(e.service, true, true)
+		}
+	}
+	return e.Fake.Execute(subcommand, args...)
+}
+
+func TestWaitFor(t *testing.T) {
+	const service, service2 = "edgexfoundry.core-data", "edgexfoundry.core-metadata"
+
+	t.Run("converged", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+		fake.AddService(service, true, false)
+		fake.AddService(service2, true, true)
+		snapctl.SetExecutor(&startingExecutor{Fake: fake, service: service, polls: 3})
+
+		pending, err := snapctl.WaitFor(context.Background(), snapctl.StateActive, service, service2)
+		require.NoError(t, err)
+		require.Empty(t, pending)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		fake := snapctltest.Install(t)
+		fake.AddService(service, true, true)
+		fake.AddService(service2, true, true)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		pending, err := snapctl.WaitFor(ctx, snapctl.StateInactive, service2, service)
+		require.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+		require.Equal(t, []string{service, service2}, pending)
+	})
+
+	t.Run("service not found", func(t *testing.T) {
+		snapctltest.Install(t)
+
+		_, err := snapctl.WaitFor(context.Background(), snapctl.StateActive, service)
+		require.Error(t, err)
+	})
+
+	t.Run("reject unknown state", func(t *testing.T) {
+		_, err := snapctl.WaitFor(context.Background(), snapctl.StateUnknown, service)
+		require.Error(t, err)
+	})
+}